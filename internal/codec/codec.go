@@ -17,9 +17,15 @@
 
 package codec
 
+import (
+	"reflect"
+	"sync"
+)
+
 import (
 	hessian "github.com/apache/dubbo-go-hessian2"
 	"github.com/golang/protobuf/proto"
+	perrors "github.com/pkg/errors"
 )
 
 import (
@@ -31,6 +37,7 @@ func init() {
 	common.SetDubbo3Serializer(common.PBSerializerName, NewProtobufCodeC)
 	common.SetDubbo3Serializer(common.HessianSerializerName, NewHessianCodeC)
 	common.SetDubbo3Serializer(common.TripleHessianWrapperSerializerName, NewTripleHessianWrapperSerializer)
+	common.SetDubbo3Serializer(common.JSONSerializerName, NewJSONCodeC)
 }
 
 // ProtobufCodeC is the protobuf impl of Dubbo3Serializer interface
@@ -84,6 +91,41 @@ func (h *HessianCodeC) MarshalRequest(v interface{}) ([]byte, error) {
 
 type HessianUnmarshalStruct struct {
 	Val interface{}
+	// ArgTypes are java class names of args, which are set when request of TripleHessianWrapperSerializer is unmarshalled
+	ArgTypes []string
+
+	// rawArgs are serialized args of inner serialization innerCodeC, which are kept if the args don't carry their types
+	rawArgs    [][]byte
+	innerCodeC wrapperInnerCodeC
+}
+
+// UnmarshalArgs deserializes args of request of TripleHessianWrapperSerializer again to values of @types,
+// which are go types of params of the invoked method. Args of inner serialization without types, like json,
+// are generic values before, e.g. json.Number and map, while args of hessian2 keep their values.
+func (h *HessianUnmarshalStruct) UnmarshalArgs(types []reflect.Type) error {
+	if h.innerCodeC == nil {
+		return nil
+	}
+	if len(types) != len(h.rawArgs) {
+		return perrors.Errorf("method has %d params, but got %d args", len(types), len(h.rawArgs))
+	}
+	args := make([]interface{}, 0, len(types))
+	for i, typ := range types {
+		ptr := typ.Kind() == reflect.Ptr
+		if ptr {
+			typ = typ.Elem()
+		}
+		v := reflect.New(typ)
+		if err := h.innerCodeC.unmarshalTo(h.rawArgs[i], v.Interface()); err != nil {
+			return err
+		}
+		if !ptr {
+			v = v.Elem()
+		}
+		args = append(args, v.Interface())
+	}
+	h.Val = args
+	return nil
 }
 
 func (h *HessianCodeC) UnmarshalRequest(data []byte, v interface{}) error {
@@ -106,77 +148,171 @@ func NewHessianCodeC() common.Dubbo3Serializer {
 	return &HessianCodeC{}
 }
 
-// TripleHessianWrapperSerializer
+// TripleHessianWrapperSerializer serializes args in triple wrapper, which is TripleRequestWrapper or
+// TripleResponseWrapper of protobuf. Args are serialized by the inner serialization, which is hessian2 by default,
+// or json and fastjson, and it's written in SerializeType of wrapper. Received args are deserialized by
+// the inner serialization of their SerializeType.
 type TripleHessianWrapperSerializer struct {
-	pbSerializer      common.Dubbo3Serializer
-	hessianSerializer common.Dubbo3Serializer
+	pbSerializer common.Dubbo3Serializer
+	// serializeType is inner serialization of sent args
+	serializeType string
+	// replyInKind is true on server, whose sent args are serialized by inner serialization of received args
+	replyInKind bool
+	lock        sync.RWMutex
 }
 
+// NewTripleHessianWrapperSerializer returns new TripleHessianWrapperSerializer whose inner serialization is hessian2
 func NewTripleHessianWrapperSerializer() common.Dubbo3Serializer {
 	return &TripleHessianWrapperSerializer{
-		pbSerializer:      NewProtobufCodeC(),
-		hessianSerializer: NewHessianCodeC(),
+		pbSerializer:  NewProtobufCodeC(),
+		serializeType: string(common.HessianSerializerName),
+	}
+}
+
+// NewTripleWrapperSerializer returns new TripleHessianWrapperSerializer whose inner serialization is @serializeType,
+// which is one of "hessian2", "json" and "fastjson"
+func NewTripleWrapperSerializer(serializeType string) (common.Dubbo3Serializer, error) {
+	if _, ok := wrapperInnerCodeCs[serializeType]; !ok {
+		return nil, perrors.Errorf("triple wrapper serialize type %s is not supported", serializeType)
+	}
+	return &TripleHessianWrapperSerializer{
+		pbSerializer:  NewProtobufCodeC(),
+		serializeType: serializeType,
+	}, nil
+}
+
+// NewTripleWrapperServerSerializer returns new TripleHessianWrapperSerializer of server, whose responses are serialized
+// by inner serialization of requests, so that client receives responses in the serialization that it sends
+func NewTripleWrapperServerSerializer() common.Dubbo3Serializer {
+	return &TripleHessianWrapperSerializer{
+		pbSerializer:  NewProtobufCodeC(),
+		serializeType: string(common.HessianSerializerName),
+		replyInKind:   true,
 	}
 }
 
+// sendCodeC returns inner serialization and its codec of sent args
+func (h *TripleHessianWrapperSerializer) sendCodeC() (string, wrapperInnerCodeC) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.serializeType, wrapperInnerCodeCs[h.serializeType]
+}
+
+// recvCodeC returns codec of received args whose inner serialization is @serializeType
+func (h *TripleHessianWrapperSerializer) recvCodeC(serializeType string) (wrapperInnerCodeC, error) {
+	if serializeType == "" {
+		// wrapper of old version doesn't carry serialize type
+		serializeType = string(common.HessianSerializerName)
+	}
+	innerCodeC, ok := wrapperInnerCodeCs[serializeType]
+	if !ok {
+		return nil, perrors.Errorf("triple wrapper serialize type %s is not supported", serializeType)
+	}
+	if h.replyInKind {
+		h.lock.Lock()
+		h.serializeType = serializeType
+		h.lock.Unlock()
+	}
+	return innerCodeC, nil
+}
+
+// MarshalRequest serializes args @v of unary invocation, or a message of streaming invocation, which is the only arg
 func (h *TripleHessianWrapperSerializer) MarshalRequest(v interface{}) ([]byte, error) {
-	args := v.([]interface{})
+	args, ok := v.([]interface{})
+	if !ok {
+		args = []interface{}{v}
+	}
+	serializeType, innerCodeC := h.sendCodeC()
 	argsBytes := make([][]byte, 0)
 	argsTypes := make([]string, 0)
 	for _, v := range args {
-		data, err := h.hessianSerializer.MarshalRequest(v)
+		argType := getArgType(v)
+		if arg, ok := v.(common.JavaArg); ok {
+			// value is sent with explicit java class name
+			v = arg.Value
+		}
+		data, err := innerCodeC.marshal(v)
 		if err != nil {
 			return nil, err
 		}
 		argsBytes = append(argsBytes, data)
-		argsTypes = append(argsTypes, getArgType(v))
+		argsTypes = append(argsTypes, argType)
 	}
 
 	wrapperRequest := &proto2.TripleRequestWrapper{
-		SerializeType: string(common.HessianSerializerName),
+		SerializeType: serializeType,
 		Args:          argsBytes,
-		// todo to java name
-		ArgTypes: argsTypes,
+		ArgTypes:      argsTypes,
 	}
 	return h.pbSerializer.MarshalRequest(wrapperRequest)
 }
 
+// UnmarshalRequest deserializes args to @v if it's *HessianUnmarshalStruct, otherwise the only arg,
+// which is a message of streaming invocation, is deserialized to @v
 func (h *TripleHessianWrapperSerializer) UnmarshalRequest(data []byte, v interface{}) error {
 	wrapperRequest := proto2.TripleRequestWrapper{}
 	err := h.pbSerializer.UnmarshalRequest(data, &wrapperRequest)
 	if err != nil {
 		return err
 	}
-	args := []interface{}{}
-	for _, v := range wrapperRequest.Args {
-		arg := HessianUnmarshalStruct{}
-		if err := h.hessianSerializer.UnmarshalRequest(v, &arg); err != nil {
-			return err
+	innerCodeC, err := h.recvCodeC(wrapperRequest.SerializeType)
+	if err != nil {
+		return err
+	}
+	if out, ok := v.(*HessianUnmarshalStruct); ok {
+		args := []interface{}{}
+		for _, v := range wrapperRequest.Args {
+			arg, err := innerCodeC.unmarshal(v)
+			if err != nil {
+				return err
+			}
+			args = append(args, arg)
+		}
+		out.Val = args
+		out.ArgTypes = wrapperRequest.ArgTypes
+		if !innerCodeC.typed() {
+			out.rawArgs, out.innerCodeC = wrapperRequest.Args, innerCodeC
 		}
-		args = append(args, arg.Val)
+		return nil
 	}
-	v.(*HessianUnmarshalStruct).Val = args
-	return nil
+	if len(wrapperRequest.Args) != 1 {
+		return perrors.Errorf("stream message should be one arg, but got %d args", len(wrapperRequest.Args))
+	}
+	return innerCodeC.unmarshalTo(wrapperRequest.Args[0], v)
 }
 
 func (h *TripleHessianWrapperSerializer) MarshalResponse(v interface{}) ([]byte, error) {
-	data, err := h.hessianSerializer.MarshalResponse(v)
+	argType := getArgType(v)
+	if arg, ok := v.(common.JavaArg); ok {
+		v = arg.Value
+	}
+	serializeType, innerCodeC := h.sendCodeC()
+	data, err := innerCodeC.marshal(v)
 	if err != nil {
 		return nil, err
 	}
 	wrapperRequest := &proto2.TripleResponseWrapper{
-		SerializeType: string(common.HessianSerializerName),
+		SerializeType: serializeType,
 		Data:          data,
-		Type:          getArgType(v),
+		Type:          argType,
 	}
 	return h.pbSerializer.MarshalResponse(wrapperRequest)
 }
 
+// UnmarshalResponse deserializes response to @v if it's *HessianUnmarshalStruct, otherwise it's deserialized to @v
 func (h *TripleHessianWrapperSerializer) UnmarshalResponse(data []byte, v interface{}) error {
 	wrapperResponse := proto2.TripleResponseWrapper{}
 	err := h.pbSerializer.UnmarshalResponse(data, &wrapperResponse)
 	if err != nil {
 		return err
 	}
-	return h.hessianSerializer.UnmarshalResponse(wrapperResponse.Data, v)
+	innerCodeC, err := h.recvCodeC(wrapperResponse.SerializeType)
+	if err != nil {
+		return err
+	}
+	if out, ok := v.(*HessianUnmarshalStruct); ok {
+		out.Val, err = innerCodeC.unmarshal(wrapperResponse.Data)
+		return err
+	}
+	return innerCodeC.unmarshalTo(wrapperResponse.Data, v)
 }
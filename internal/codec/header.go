@@ -19,9 +19,13 @@ package codec
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 	"net/http"
 	"net/textproto"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 import (
@@ -29,6 +33,9 @@ import (
 	constant "github.com/dubbogo/gost/dubbogo/constant"
 
 	h2Triple "github.com/dubbogo/net/http2/triple"
+
+	"github.com/golang/protobuf/proto"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
 )
 
 import (
@@ -49,8 +56,20 @@ const (
 	// TrailerKeyGrpcMessage is a trailer header field to response grpc error message.
 	TrailerKeyGrpcMessage = "grpc-message"
 
+	// TrailerKeyGrpcStatusDetailsBin is a trailer header field to response serialized google.rpc.Status (base64),
+	// which contains error details besides code and message.
+	TrailerKeyGrpcStatusDetailsBin = "grpc-status-details-bin"
+
 	// TrailerKeyTraceProtoBin is triple trailer header
 	TrailerKeyTraceProtoBin = "trace-proto-bin"
+
+	// TrailerKeyEndpointLoadMetrics is a trailer header field to report server load, in ORCA text format,
+	// e.g. "TEXT cpu_utilization=0.25, named_metrics.inflight=3"
+	TrailerKeyEndpointLoadMetrics = "endpoint-load-metrics"
+
+	// TrailerKeyGrpcRetryPushbackMs is a trailer header field for server to ask client to retry after the milliseconds,
+	// negative or invalid value means not to retry
+	TrailerKeyGrpcRetryPushbackMs = "grpc-retry-pushback-ms"
 )
 
 const (
@@ -62,6 +81,9 @@ const (
 	TripleTraceRPCID = "tri-trace-rpcid"
 	TripleTraceProtoBin = "tri-trace-proto-bin"
 	TripleUnitInfo = "tri-unit-info"
+	// TripleSerializer is sent by clients of triple wrapper serializer, whose requests are of the same content-type
+	// as protobuf ones, so that server serving both of them knows how to deserialize the request
+	TripleSerializer = "tri-serializer"
 )
 
 // TripleHeader stores the needed http2 header fields of triple protocol
@@ -145,11 +167,103 @@ func (t *TripleHeaderHandler) WriteTripleReqHeaderField(header http.Header) http
 // WriteTripleFinalRspHeaderField returns trailers header fields that triple and grpc defined
 func (t *TripleHeaderHandler) WriteTripleFinalRspHeaderField(w http.ResponseWriter, grpcStatusCode int, grpcMessage string, traceProtoBin int) {
 	w.Header().Set(TrailerKeyGrpcStatus, strconv.Itoa(grpcStatusCode)) // sendMsg.st.Code()
-	w.Header().Set(TrailerKeyGrpcMessage, grpcMessage)                 //encodeGrpcMessage(""))
+	w.Header().Set(TrailerKeyGrpcMessage, EncodeGrpcMessage(grpcMessage))
 	// todo now if add this field, java-provider may caused unexpected error.
 	//w.Header().Set(TrailerKeyTraceProtoBin, strconv.Itoa(traceProtoBin)) // sendMsg.st.Code()
 }
 
+// EncodeGrpcMessage percent-encodes @msg as grpc http2 spec defines for grpc-message field:
+// bytes outside printable ascii and '%' itself are encoded as "%XX", invalid utf8 is replaced with U+FFFD.
+func EncodeGrpcMessage(msg string) string {
+	if !needEncodeGrpcMessage(msg) {
+		return msg
+	}
+	var sb strings.Builder
+	for len(msg) > 0 {
+		r, size := utf8.DecodeRuneInString(msg)
+		// r is utf8.RuneError if msg is invalid utf8, which is encoded as U+FFFD
+		for _, b := range []byte(string(r)) {
+			if size == 1 && isGrpcMessageASCII(b) {
+				sb.WriteByte(b)
+			} else {
+				fmt.Fprintf(&sb, "%%%02X", b)
+			}
+		}
+		msg = msg[size:]
+	}
+	return sb.String()
+}
+
+// DecodeGrpcMessage decodes grpc-message field @msg that is percent-encoded.
+// Invalid or truncated "%XX" sequences are kept as they are, and invalid utf8 result is replaced with U+FFFD.
+func DecodeGrpcMessage(msg string) string {
+	if !strings.Contains(msg, "%") {
+		return msg
+	}
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		if msg[i] == '%' && i+2 < len(msg) {
+			if b, err := strconv.ParseUint(msg[i+1:i+3], 16, 8); err == nil {
+				sb.WriteByte(byte(b))
+				i += 2
+				continue
+			}
+		}
+		sb.WriteByte(msg[i])
+	}
+	decoded := sb.String()
+	if !utf8.ValidString(decoded) {
+		// each invalid byte is replaced with U+FFFD, as EncodeGrpcMessage does
+		decoded = string([]rune(decoded))
+	}
+	return decoded
+}
+
+// needEncodeGrpcMessage returns true if @msg contains byte that should be percent-encoded
+func needEncodeGrpcMessage(msg string) bool {
+	for i := 0; i < len(msg); i++ {
+		if !isGrpcMessageASCII(msg[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGrpcMessageASCII returns true if @b can be sent in grpc-message as it is
+func isGrpcMessageASCII(b byte) bool {
+	return b >= ' ' && b <= '~' && b != '%'
+}
+
+// EncodeGrpcStatusDetails returns value of grpc-status-details-bin trailer field, which is unpadded base64 of serialized @st
+func EncodeGrpcStatusDetails(st *spb.Status) (string, error) {
+	data, err := proto.Marshal(st)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(data), nil
+}
+
+// DecodeGrpcStatusDetails parses grpc-status-details-bin trailer field @value, both padded and unpadded base64 are accepted
+func DecodeGrpcStatusDetails(value string) (*spb.Status, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if len(value)%4 == 0 {
+		data, err = base64.StdEncoding.DecodeString(value)
+	} else {
+		data, err = base64.RawStdEncoding.DecodeString(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	st := &spb.Status{}
+	if err := proto.Unmarshal(data, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
 // getCtxVaSave get key @fields value and return, if not exist, return empty string
 func getCtxVaSave(ctx context.Context, field string) string {
 	val, ok := ctx.Value(field).(string)
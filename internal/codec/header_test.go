@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeGrpcMessage(t *testing.T) {
+	for _, c := range []struct {
+		msg  string
+		want string
+	}{
+		{"", ""},
+		{"Hello", "Hello"},
+		{"\u0000", "%00"},
+		{"%", "%25"},
+		{"100% done", "100%25 done"},
+		{"line\nbreak", "line%0Abreak"},
+		{"系统", "%E7%B3%BB%E7%BB%9F"},
+		{"\xff", "%EF%BF%BD"},
+		{"~ \x7f", "~ %7F"},
+	} {
+		assert.Equal(t, c.want, EncodeGrpcMessage(c.msg), c.msg)
+	}
+}
+
+func TestDecodeGrpcMessage(t *testing.T) {
+	for _, c := range []struct {
+		msg  string
+		want string
+	}{
+		{"", ""},
+		{"Hello", "Hello"},
+		{"H%61o", "Hao"},
+		{"H%6", "H%6"},
+		{"%", "%"},
+		{"%%", "%%"},
+		{"%%41", "%A"},
+		{"%4G", "%4G"},
+		{"%+1", "%+1"},
+		{"%0A%0d", "\n\r"},
+		{"%E7%B3%BB%E7%BB%9F", "系统"},
+		// truncated utf8 is replaced
+		{"%E7%B3", "��"},
+		{"%FF", "�"},
+		{"%FF%FE", "��"},
+	} {
+		assert.Equal(t, c.want, DecodeGrpcMessage(c.msg), c.msg)
+	}
+
+	for _, msg := range []string{"100% done", "multi\nline", "中文 message", "emoji 😀", "\x01\x7f"} {
+		assert.Equal(t, msg, DecodeGrpcMessage(EncodeGrpcMessage(msg)))
+	}
+}
@@ -19,6 +19,7 @@ package codec
 
 import (
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -26,96 +27,145 @@ import (
 	hessian "github.com/apache/dubbo-go-hessian2"
 )
 
-// getArgType is copied from hessian, to get java class type of interface
+import (
+	"github.com/dubbogo/triple/pkg/common"
+)
+
+const (
+	javaObject = "java.lang.Object"
+
+	// javaTimeHandlePrefix prefixes hessian handle classes of java.time types, which are java class names of
+	// hessian java8_time pojos, like com.alibaba.com.caucho.hessian.io.java8.LocalDateHandle
+	javaTimeHandlePrefix = "com.alibaba.com.caucho.hessian.io.java8."
+	javaTimeHandleSuffix = "Handle"
+)
+
+var (
+	pojoType   = reflect.TypeOf((*hessian.POJO)(nil)).Elem()
+	objectType = reflect.TypeOf((*hessian.Object)(nil)).Elem()
+)
+
+// javaSpecialTypes are go types in standard library that hessian encodes as java classes,
+// big numbers are encoded as java.math.BigInteger and java.math.BigDecimal only if they are gxbig pojos
+var javaSpecialTypes = map[reflect.Type]string{
+	reflect.TypeOf(time.Time{}): "java.util.Date",
+}
+
+// javaPrimitives are java primitive types of go basic kinds
+var javaPrimitives = map[reflect.Kind]string{
+	reflect.Bool:    "boolean",
+	reflect.Int8:    "byte",
+	reflect.Uint8:   "byte",
+	reflect.Int16:   "short",
+	reflect.Uint16:  "char",
+	reflect.Int32:   "int",
+	reflect.Int:     "long",
+	reflect.Int64:   "long",
+	reflect.Uint:    "long",
+	reflect.Uint32:  "long",
+	reflect.Uint64:  "long",
+	reflect.Float32: "float",
+	reflect.Float64: "double",
+}
+
+// javaBoxedTypes are java boxed types of pointers to go basic kinds
+var javaBoxedTypes = map[reflect.Kind]string{
+	reflect.Bool:    "java.lang.Boolean",
+	reflect.Int8:    "java.lang.Byte",
+	reflect.Uint8:   "java.lang.Byte",
+	reflect.Int16:   "java.lang.Short",
+	reflect.Uint16:  "java.lang.Character",
+	reflect.Int32:   "java.lang.Integer",
+	reflect.Int:     "java.lang.Long",
+	reflect.Int64:   "java.lang.Long",
+	reflect.Uint:    "java.lang.Long",
+	reflect.Uint32:  "java.lang.Long",
+	reflect.Uint64:  "java.lang.Long",
+	reflect.Float32: "java.lang.Float",
+	reflect.Float64: "java.lang.Double",
+}
+
+// javaArrayCodes are codes of java primitive types in names of array classes
+var javaArrayCodes = map[string]string{
+	"boolean": "Z",
+	"byte":    "B",
+	"char":    "C",
+	"short":   "S",
+	"int":     "I",
+	"long":    "J",
+	"float":   "F",
+	"double":  "D",
+}
+
+// getArgType returns java class name of @v, as java Class.getName() returns, which is the arg type that
+// java triple server uses to find method. Names set by common.SetJavaType override the default mapping.
 func getArgType(v interface{}) string {
 	if v == nil {
-		return "V"
+		return javaObject
 	}
+	if arg, ok := v.(common.JavaArg); ok {
+		return arg.Type
+	}
+	return JavaClassName(reflect.TypeOf(v))
+}
 
-	switch v.(type) {
-	// Serialized tags for base types
-	case nil:
-		return "V"
-	case bool:
-		return "Z"
-	case []bool:
-		return "[Z"
-	case byte:
-		return "B"
-	case []byte:
-		return "[B"
-	case int8:
-		return "B"
-	case []int8:
-		return "[B"
-	case int16:
-		return "S"
-	case []int16:
-		return "[S"
-	case uint16: // Equivalent to Char of Java
-		return "C"
-	case []uint16:
-		return "[C"
-	// case rune:
-	//	return "C"
-	case int:
-		return "J"
-	case []int:
-		return "[J"
-	case int32:
-		return "I"
-	case []int32:
-		return "[I"
-	case int64:
-		return "J"
-	case []int64:
-		return "[J"
-	case time.Time:
-		return "java.util.Date"
-	case []time.Time:
-		return "[Ljava.util.Date"
-	case float32:
-		return "F"
-	case []float32:
-		return "[F"
-	case float64:
-		return "D"
-	case []float64:
-		return "[D"
-	case string:
-		return "java.lang.String"
-	case []string:
-		return "[Ljava.lang.String;"
-	case []hessian.Object:
-		return "[Ljava.lang.Object;"
-	case map[interface{}]interface{}:
-		// return  "java.util.HashMap"
-		return "java.util.Map"
-	case hessian.POJOEnum:
-		return v.(hessian.POJOEnum).JavaClassName()
-	//  Serialized tags for complex types
-	default:
-		t := reflect.TypeOf(v)
-		if reflect.Ptr == t.Kind() {
-			t = reflect.TypeOf(reflect.ValueOf(v).Elem())
+// JavaClassName returns java class name of go type @t, which is the arg type of its values
+func JavaClassName(t reflect.Type) string {
+	if name, ok := common.GetJavaType(t); ok {
+		return name
+	}
+	if name, ok := javaSpecialTypes[t]; ok {
+		return name
+	}
+	if t.Implements(pojoType) {
+		// pojo of pointer type is created, so that method with value receiver can be called by it
+		v := reflect.Zero(t)
+		if t.Kind() == reflect.Ptr {
+			v = reflect.New(t.Elem())
 		}
-		switch t.Kind() {
-		case reflect.Struct:
-			v, ok := v.(hessian.POJO)
-			if ok {
-				return v.JavaClassName()
-			}
-			return "java.lang.Object"
-		case reflect.Slice, reflect.Array:
-			if t.Elem().Kind() == reflect.Struct {
-				return "[Ljava.lang.Object;"
+		return javaTimeClassName(v.Interface().(hessian.POJO).JavaClassName())
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		// pointer to basic kind is nullable boxed type, and pointer to others is the same as its element
+		if _, set := common.GetJavaType(t.Elem()); !set {
+			if boxed, ok := javaBoxedTypes[t.Elem().Kind()]; ok {
+				return boxed
 			}
-			// return "java.util.ArrayList"
+		}
+		return JavaClassName(t.Elem())
+	case reflect.String:
+		return "java.lang.String"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Interface && t.Elem() != objectType {
+			// []interface{} is hessian untyped list
 			return "java.util.List"
-		case reflect.Map: // Enter here, map may be map[string]int
-			return "java.util.Map"
-		default:
-			return ""
 		}
+		return javaArrayClassName(JavaClassName(t.Elem()))
+	case reflect.Map:
+		return "java.util.Map"
+	}
+	if name, ok := javaPrimitives[t.Kind()]; ok {
+		return name
+	}
+	return javaObject
+}
+
+// javaTimeClassName returns java.time class of hessian handle class @name, or @name if it's not a handle class
+func javaTimeClassName(name string) string {
+	if !strings.HasPrefix(name, javaTimeHandlePrefix) || !strings.HasSuffix(name, javaTimeHandleSuffix) {
+		return name
+	}
+	return "java.time." + strings.TrimSuffix(strings.TrimPrefix(name, javaTimeHandlePrefix), javaTimeHandleSuffix)
+}
+
+// javaArrayClassName returns java class name of array whose element class name is @elem
+func javaArrayClassName(elem string) string {
+	if code, ok := javaArrayCodes[elem]; ok {
+		return "[" + code
+	}
+	if strings.HasPrefix(elem, "[") {
+		return "[" + elem
 	}
+	return "[L" + elem + ";"
 }
@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+import (
+	hessian "github.com/apache/dubbo-go-hessian2"
+	"github.com/apache/dubbo-go-hessian2/java8_time"
+	"github.com/apache/dubbo-go-hessian2/java_sql_time"
+	gxbig "github.com/dubbogo/gost/math/big"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/common"
+)
+
+type javaTestUser struct {
+	Name string
+}
+
+func (javaTestUser) JavaClassName() string {
+	return "com.demo.User"
+}
+
+type javaTestStatus int32
+
+func (javaTestStatus) JavaClassName() string {
+	return "com.demo.Status"
+}
+
+type javaTestPlain struct{}
+
+// javaTestUsers is mapped to java.util.List by common.SetJavaType
+type javaTestUsers []javaTestUser
+
+// javaTestCode is mapped to com.demo.Code by common.SetJavaType
+type javaTestCode int64
+
+func TestGetArgType(t *testing.T) {
+	common.SetJavaType(reflect.TypeOf(javaTestUsers{}), "java.util.List")
+	common.SetJavaType(reflect.TypeOf(javaTestCode(0)), "com.demo.Code")
+	i32, i64, str, f64, flag, ch := int32(1), int64(1), "s", 1.0, true, uint16('c')
+	code := javaTestCode(1)
+
+	for _, c := range []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, "java.lang.Object"},
+		{common.JavaArg{Type: "java.lang.Object", Value: "hello"}, "java.lang.Object"},
+		// primitives
+		{true, "boolean"},
+		{int8(1), "byte"},
+		{byte(1), "byte"},
+		{int16(1), "short"},
+		{uint16(1), "char"},
+		{int32(1), "int"},
+		{1, "long"},
+		{int64(1), "long"},
+		{uint32(1), "long"},
+		{float32(1), "float"},
+		{1.0, "double"},
+		{"s", "java.lang.String"},
+		// pointers to basic kinds are boxed types
+		{&flag, "java.lang.Boolean"},
+		{&ch, "java.lang.Character"},
+		{&i32, "java.lang.Integer"},
+		{&i64, "java.lang.Long"},
+		{&f64, "java.lang.Double"},
+		{&str, "java.lang.String"},
+		// arrays of primitives, boxed types and classes
+		{[]bool{}, "[Z"},
+		{[]byte{}, "[B"},
+		{[]uint16{}, "[C"},
+		{[]int32{}, "[I"},
+		{[]int{}, "[J"},
+		{[3]float32{}, "[F"},
+		{[]float64{}, "[D"},
+		{[]*int32{}, "[Ljava.lang.Integer;"},
+		{[]string{}, "[Ljava.lang.String;"},
+		{[][]int32{}, "[[I"},
+		{[][]string{}, "[[Ljava.lang.String;"},
+		{[]javaTestUser{}, "[Lcom.demo.User;"},
+		{[]*javaTestUser{}, "[Lcom.demo.User;"},
+		{[]hessian.Object{}, "[Ljava.lang.Object;"},
+		{[]interface{}{}, "java.util.List"},
+		// maps
+		{map[interface{}]interface{}{}, "java.util.Map"},
+		{map[string][]int32{}, "java.util.Map"},
+		// pojos and enums, by value or pointer
+		{javaTestUser{}, "com.demo.User"},
+		{&javaTestUser{}, "com.demo.User"},
+		{javaTestStatus(1), "com.demo.Status"},
+		{[]javaTestStatus{}, "[Lcom.demo.Status;"},
+		{javaTestPlain{}, "java.lang.Object"},
+		{&javaTestPlain{}, "java.lang.Object"},
+		// big numbers
+		// big numbers of standard library aren't encoded as java big numbers
+		{big.NewInt(1), "java.lang.Object"},
+		{big.NewFloat(1), "java.lang.Object"},
+		{&gxbig.Integer{}, "java.math.BigInteger"},
+		{&gxbig.Decimal{}, "java.math.BigDecimal"},
+		// date and time
+		{time.Now(), "java.util.Date"},
+		{&time.Time{}, "java.util.Date"},
+		{[]time.Time{}, "[Ljava.util.Date;"},
+		{java_sql_time.Date{}, "java.sql.Date"},
+		{java8_time.LocalDate{}, "java.time.LocalDate"},
+		{&java8_time.ZonedDateTime{}, "java.time.ZonedDateTime"},
+		// types set by common.SetJavaType
+		{javaTestUsers{}, "java.util.List"},
+		{code, "com.demo.Code"},
+		{&code, "com.demo.Code"},
+		{[]javaTestCode{}, "[Lcom.demo.Code;"},
+	} {
+		assert.Equal(t, c.want, getArgType(c.v), "%T", c.v)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"encoding/json"
+)
+
+import (
+	protoV1 "github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/common"
+)
+
+// JSONCodeC is the json impl of Dubbo3Serializer interface, pb messages are serialized in protobuf json format,
+// and other values are serialized by encoding/json
+type JSONCodeC struct{}
+
+// NewJSONCodeC returns new JSONCodeC
+func NewJSONCodeC() common.Dubbo3Serializer {
+	return &JSONCodeC{}
+}
+
+// ContentSubtype returns "json", content-type of its payload is application/grpc+json
+func (j *JSONCodeC) ContentSubtype() string {
+	return "json"
+}
+
+// AsProtoMessage returns @v as pb message, it returns false if @v is not a pb message
+func AsProtoMessage(v interface{}) (proto.Message, bool) {
+	switch m := v.(type) {
+	case proto.Message:
+		return m, true
+	case protoV1.Message:
+		return protoV1.MessageV2(m), true
+	}
+	return nil, false
+}
+
+// MarshalRequest serialize @v to json
+func (j *JSONCodeC) MarshalRequest(v interface{}) ([]byte, error) {
+	if m, ok := AsProtoMessage(v); ok {
+		return protojson.Marshal(m)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalRequest deserialize json @data to @v, unknown fields of pb message are ignored
+func (j *JSONCodeC) UnmarshalRequest(data []byte, v interface{}) error {
+	if m, ok := AsProtoMessage(v); ok {
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// MarshalResponse serialize @v to json
+func (j *JSONCodeC) MarshalResponse(v interface{}) ([]byte, error) {
+	return j.MarshalRequest(v)
+}
+
+// UnmarshalResponse deserialize json @data to @v
+func (j *JSONCodeC) UnmarshalResponse(data []byte, v interface{}) error {
+	return j.UnmarshalRequest(data, v)
+}
@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/common"
+)
+
+func TestJSONCodeC(t *testing.T) {
+	codec, err := common.GetDubbo3Serializer(common.JSONSerializerName)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/grpc+json", common.GetContentType(codec))
+	assert.Equal(t, "application/grpc+proto", common.GetContentType(NewProtobufCodeC()))
+
+	// pb message is serialized in protobuf json format
+	data, err := codec.MarshalRequest(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"status":"SERVING"}`, string(data))
+	rsp := &healthpb.HealthCheckResponse{}
+	assert.Nil(t, codec.UnmarshalResponse([]byte(`{"status":"NOT_SERVING","unknown":1}`), rsp))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+	data, err = codec.MarshalResponse(wrapperspb.String("hello"))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `"hello"`, string(data))
+
+	// other values are serialized by encoding/json
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	data, err = codec.MarshalRequest(&user{Name: "laurence", Age: 20})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"name":"laurence","age":20}`, string(data))
+	u := &user{}
+	assert.Nil(t, codec.UnmarshalRequest(data, u))
+	assert.Equal(t, user{Name: "laurence", Age: 20}, *u)
+}
@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/common"
+)
+
+// RawCodeC is the Dubbo3Serializer that doesn't serialize, it sends []byte or *[]byte as it is,
+// and receives payload into *[]byte. It's used by streams whose message types are unknown, e.g. proxies.
+type RawCodeC struct {
+	// subtype is content-subtype of payload
+	subtype string
+}
+
+// NewRawCodeC returns new RawCodeC, whose payload is of content-subtype "proto"
+func NewRawCodeC() common.Dubbo3Serializer {
+	return NewRawCodeCWithSubtype("proto")
+}
+
+// NewRawCodeCWithSubtype returns new RawCodeC, whose payload is of content-subtype @subtype
+func NewRawCodeCWithSubtype(subtype string) common.Dubbo3Serializer {
+	return &RawCodeC{subtype: subtype}
+}
+
+// ContentSubtype returns content-subtype of payload
+func (r *RawCodeC) ContentSubtype() string {
+	return r.subtype
+}
+
+// MarshalRequest returns @v as raw payload
+func (r *RawCodeC) MarshalRequest(v interface{}) ([]byte, error) {
+	switch data := v.(type) {
+	case []byte:
+		return data, nil
+	case *[]byte:
+		return *data, nil
+	}
+	return nil, perrors.Errorf("raw codec can't marshal %T, it must be []byte or *[]byte", v)
+}
+
+// UnmarshalRequest sets copy of raw payload @data to @v
+func (r *RawCodeC) UnmarshalRequest(data []byte, v interface{}) error {
+	p, ok := v.(*[]byte)
+	if !ok {
+		return perrors.Errorf("raw codec can't unmarshal to %T, it must be *[]byte", v)
+	}
+	*p = append([]byte(nil), data...)
+	return nil
+}
+
+// MarshalResponse returns @v as raw payload
+func (r *RawCodeC) MarshalResponse(v interface{}) ([]byte, error) {
+	return r.MarshalRequest(v)
+}
+
+// UnmarshalResponse sets copy of raw payload @data to @v
+func (r *RawCodeC) UnmarshalResponse(data []byte, v interface{}) error {
+	return r.UnmarshalRequest(data, v)
+}
@@ -0,0 +1,103 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+import (
+	hessian "github.com/apache/dubbo-go-hessian2"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/common"
+)
+
+// wrapperInnerCodeC is inner serialization of args in triple wrapper
+type wrapperInnerCodeC interface {
+	// marshal serializes arg @v
+	marshal(v interface{}) ([]byte, error)
+	// unmarshal deserializes arg @data to its generic value
+	unmarshal(data []byte) (interface{}, error)
+	// typed returns true if serialized args carry their types, so that unmarshal returns values of their types
+	typed() bool
+	// unmarshalTo deserializes arg @data to @v
+	unmarshalTo(data []byte, v interface{}) error
+}
+
+// wrapperInnerCodeCs are inner serializations by serialize type of triple wrapper,
+// fastjson of java writes args as plain json, which is the same as json
+var wrapperInnerCodeCs = map[string]wrapperInnerCodeC{
+	string(common.HessianSerializerName):  hessianInnerCodeC{},
+	string(common.JSONSerializerName):     jsonInnerCodeC{},
+	string(common.FastJSONSerializerName): jsonInnerCodeC{},
+}
+
+// hessianInnerCodeC serializes args by hessian2
+type hessianInnerCodeC struct{}
+
+func (hessianInnerCodeC) marshal(v interface{}) ([]byte, error) {
+	return NewHessianCodeC().MarshalRequest(v)
+}
+
+func (hessianInnerCodeC) unmarshal(data []byte) (interface{}, error) {
+	out := HessianUnmarshalStruct{}
+	err := NewHessianCodeC().UnmarshalRequest(data, &out)
+	return out.Val, err
+}
+
+func (hessianInnerCodeC) typed() bool {
+	return true
+}
+
+func (c hessianInnerCodeC) unmarshalTo(data []byte, v interface{}) error {
+	val, err := c.unmarshal(data)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		return nil
+	}
+	return hessian.ReflectResponse(val, v)
+}
+
+// jsonInnerCodeC serializes args by json, pb messages are in protobuf json format
+type jsonInnerCodeC struct{}
+
+func (jsonInnerCodeC) marshal(v interface{}) ([]byte, error) {
+	return NewJSONCodeC().MarshalRequest(v)
+}
+
+// unmarshal deserializes numbers to json.Number, so that they are not rounded to float64
+func (jsonInnerCodeC) unmarshal(data []byte) (interface{}, error) {
+	var val interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&val)
+	return val, err
+}
+
+func (jsonInnerCodeC) typed() bool {
+	return false
+}
+
+func (jsonInnerCodeC) unmarshalTo(data []byte, v interface{}) error {
+	return NewJSONCodeC().UnmarshalRequest(data, v)
+}
@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	proto2 "github.com/dubbogo/triple/internal/codec/proto"
+	"github.com/dubbogo/triple/pkg/common"
+)
+
+func TestTripleWrapperSerializeType(t *testing.T) {
+	for _, serializeType := range []common.TripleSerializerName{
+		common.HessianSerializerName, common.JSONSerializerName, common.FastJSONSerializerName,
+	} {
+		client, err := NewTripleWrapperSerializer(string(serializeType))
+		assert.Nil(t, err)
+		server := NewTripleWrapperServerSerializer()
+
+		// args are serialized by inner serialization, which is written in wrapper
+		data, err := client.MarshalRequest([]interface{}{"hello", int64(1)})
+		assert.Nil(t, err)
+		wrapperRequest := proto2.TripleRequestWrapper{}
+		assert.Nil(t, NewProtobufCodeC().UnmarshalRequest(data, &wrapperRequest))
+		assert.Equal(t, string(serializeType), wrapperRequest.SerializeType)
+		assert.Equal(t, []string{"java.lang.String", "long"}, wrapperRequest.ArgTypes)
+
+		args := HessianUnmarshalStruct{}
+		assert.Nil(t, server.UnmarshalRequest(data, &args))
+		if serializeType == common.HessianSerializerName {
+			assert.Equal(t, []interface{}{"hello", int64(1)}, args.Val)
+		} else {
+			// numbers of json are not rounded to float64 before they are deserialized to types of params
+			assert.Equal(t, []interface{}{"hello", json.Number("1")}, args.Val)
+			assert.NotNil(t, args.UnmarshalArgs([]reflect.Type{reflect.TypeOf("")}))
+		}
+		assert.Nil(t, args.UnmarshalArgs([]reflect.Type{reflect.TypeOf(""), reflect.TypeOf(int64(0))}))
+		assert.Equal(t, []interface{}{"hello", int64(1)}, args.Val)
+
+		// server replies in inner serialization of request
+		data, err = server.MarshalResponse("world")
+		assert.Nil(t, err)
+		wrapperResponse := proto2.TripleResponseWrapper{}
+		assert.Nil(t, NewProtobufCodeC().UnmarshalResponse(data, &wrapperResponse))
+		assert.Equal(t, string(serializeType), wrapperResponse.SerializeType)
+		var reply string
+		assert.Nil(t, NewTripleHessianWrapperSerializer().UnmarshalResponse(data, &reply))
+		assert.Equal(t, "world", reply)
+	}
+
+	_, err := NewTripleWrapperSerializer("hessian4")
+	assert.NotNil(t, err)
+	data, err := NewProtobufCodeC().MarshalRequest(&proto2.TripleRequestWrapper{SerializeType: "hessian4"})
+	assert.Nil(t, err)
+	assert.NotNil(t, NewTripleHessianWrapperSerializer().UnmarshalRequest(data, &HessianUnmarshalStruct{}))
+}
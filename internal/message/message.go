@@ -22,7 +22,11 @@ import (
 )
 
 import (
-	"github.com/dubbogo/triple/internal/status"
+	"google.golang.org/grpc/metadata"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/status"
 )
 
 ////////////////////////////////Buffer and MsgType
@@ -33,6 +37,8 @@ type Message struct {
 	MsgType MsgType
 	Status  *status.Status
 	Err     error // todo delete it, all change to status
+	// MD is the header of ServerHeaderMsgType message, or the trailer of ServerStreamCloseMsgType message
+	MD metadata.MD
 }
 
 func (bm *Message) Read(p []byte) (int, error) {
@@ -80,6 +86,16 @@ func (b *MsgChain) Put(r Message) {
 	}
 }
 
+// PutOrDone puts @r unless @done is closed first, it returns false if @r is not put
+func (b *MsgChain) PutOrDone(r Message, done <-chan struct{}) bool {
+	select {
+	case b.c <- r:
+		return true
+	case <-done:
+		return false
+	}
+}
+
 func (b *MsgChain) Get() <-chan Message {
 	return b.c
 }
@@ -26,4 +26,7 @@ const (
 
 	// ServerStreamCloseMsgType means the serverStream is to close
 	ServerStreamCloseMsgType = MsgType(2)
+
+	// ServerHeaderMsgType means the message is to send response header of serverStream
+	ServerHeaderMsgType = MsgType(3)
 )
@@ -0,0 +1,232 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+var (
+	ctxType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+	// serviceMethods are methods of common.Dubbo3GrpcService, which are never invoked by rpc
+	serviceMethods = map[string]struct{}{
+		"SetProxyImpl": {},
+		"GetProxyImpl": {},
+		"ServiceDesc":  {},
+		"MethodMapper": {},
+	}
+)
+
+// methodMapper maps go method names of service to java method names, same as the one of dubbo-go
+type methodMapper interface {
+	MethodMapper() map[string]string
+}
+
+// overloadTables caches overload table of each service type, reflect.Type -> overloadTable
+var overloadTables sync.Map
+
+// overload is a go method of service, which implements a java method with arg types @argTypes
+type overload struct {
+	// key is the name that method is registered with by MethodMapper, which is the method name of invocation
+	key      string
+	argTypes []string
+}
+
+// overloadTable maps upper cased java method names to their overloads,
+// only methods that need resolving are stored, i.e. overloaded ones or ones mapped with signature
+type overloadTable map[string][]overload
+
+// resolveOverload returns the name that the method of @service, which @methodName with java arg types @argTypes
+// refers to, is registered with. Java methods can be overloaded, and they are implemented by go methods with
+// different names. The proxy of service registers methods with names of MethodMapper, so overloads must be mapped
+// to distinct names with signatures of java arg types, e.g. "sayHello" and "sayHello(java.lang.String,int)".
+// If @methodName is neither overloaded nor mapped with signature, it is returned as it is.
+func resolveOverload(service interface{}, methodName string, argTypes []string) (string, error) {
+	overloads, ok := loadOverloadTable(service)[methodName]
+	if !ok {
+		return methodName, nil
+	}
+	if len(overloads) == 1 {
+		return overloads[0].key, nil
+	}
+	for _, o := range overloads {
+		if !equalArgTypes(o.argTypes, argTypes) {
+			continue
+		}
+		for _, other := range overloads {
+			if other.key == o.key && !equalArgTypes(other.argTypes, o.argTypes) {
+				return "", status.Errorf(codes.Unimplemented,
+					"overloads of method %s are registered with the same name %s", methodName, o.key)
+			}
+		}
+		return o.key, nil
+	}
+	return "", status.Errorf(codes.Unimplemented, "no overload of method %s matches arg types %v", methodName, argTypes)
+}
+
+// loadOverloadTable returns overload table of @service, which is built once for each service type
+func loadOverloadTable(service interface{}) overloadTable {
+	typ := reflect.TypeOf(service)
+	if table, ok := overloadTables.Load(typ); ok {
+		return table.(overloadTable)
+	}
+	table, _ := overloadTables.LoadOrStore(typ, newOverloadTable(service))
+	return table.(overloadTable)
+}
+
+// newOverloadTable reflects on methods of @service to build its overload table
+func newOverloadTable(service interface{}) overloadTable {
+	var mapper map[string]string
+	if m, ok := service.(methodMapper); ok {
+		mapper = m.MethodMapper()
+	}
+
+	all := make(overloadTable)
+	typ := reflect.TypeOf(service)
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		if _, ok := serviceMethods[method.Name]; ok {
+			continue
+		}
+		key := method.Name
+		if name, ok := mapper[method.Name]; ok && name != "" {
+			key = name
+		}
+		javaName, argTypes, ok := parseSignature(key)
+		if !ok {
+			argTypes = javaArgTypes(method.Type)
+		}
+		javaName = strings.ToUpper(javaName[:1]) + javaName[1:]
+		all[javaName] = append(all[javaName], overload{key: key, argTypes: argTypes})
+	}
+
+	table := make(overloadTable)
+	for javaName, overloads := range all {
+		if len(overloads) > 1 || strings.Contains(overloads[0].key, "(") {
+			table[javaName] = overloads
+		}
+	}
+	return table
+}
+
+// parseSignature parses method name and java arg types of signature @key like "sayHello(java.lang.String,int)",
+// it returns false if @key is a method name without signature
+func parseSignature(key string) (string, []string, bool) {
+	i := strings.Index(key, "(")
+	if i <= 0 || !strings.HasSuffix(key, ")") {
+		return key, nil, false
+	}
+	argTypes := make([]string, 0)
+	for _, argType := range strings.Split(key[i+1:len(key)-1], ",") {
+		if argType = strings.TrimSpace(argType); argType != "" {
+			argTypes = append(argTypes, argType)
+		}
+	}
+	return key[:i], argTypes, true
+}
+
+// javaArgTypes returns java class names of params of go method type @t, skipping receiver and context
+func javaArgTypes(t reflect.Type) []string {
+	types := make([]string, 0, t.NumIn())
+	for _, in := range goArgTypes(t) {
+		types = append(types, codec.JavaClassName(in))
+	}
+	return types
+}
+
+// goArgTypes returns types of params of go method type @t, skipping receiver and context
+func goArgTypes(t reflect.Type) []reflect.Type {
+	types := make([]reflect.Type, 0, t.NumIn())
+	for i := 1; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if i == 1 && in == ctxType {
+			continue
+		}
+		types = append(types, in)
+	}
+	return types
+}
+
+// methodArgTypes caches go arg types of methods of each service type, reflect.Type -> map[string][]reflect.Type,
+// methods are keyed by names they are registered with, and the ones with upper cased first letter
+var methodArgTypes sync.Map
+
+// loadMethodArgTypes returns go arg types of the method of @service registered with @methodName,
+// it returns false if no method is registered with @methodName
+func loadMethodArgTypes(service interface{}, methodName string) ([]reflect.Type, bool) {
+	typ := reflect.TypeOf(service)
+	table, ok := methodArgTypes.Load(typ)
+	if !ok {
+		table, _ = methodArgTypes.LoadOrStore(typ, newMethodArgTypes(service))
+	}
+	types, ok := table.(map[string][]reflect.Type)[methodName]
+	return types, ok
+}
+
+// newMethodArgTypes reflects on methods of @service to map their registered names to their go arg types
+func newMethodArgTypes(service interface{}) map[string][]reflect.Type {
+	var mapper map[string]string
+	if m, ok := service.(methodMapper); ok {
+		mapper = m.MethodMapper()
+	}
+
+	registered := make(map[string][]reflect.Type)
+	typ := reflect.TypeOf(service)
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		if _, ok := serviceMethods[method.Name]; ok {
+			continue
+		}
+		key := method.Name
+		if name, ok := mapper[method.Name]; ok && name != "" {
+			key = name
+		}
+		registered[key] = goArgTypes(method.Type)
+	}
+	table := make(map[string][]reflect.Type, len(registered)*2)
+	for key, types := range registered {
+		table[key] = types
+		upper := strings.ToUpper(key[:1]) + key[1:]
+		if _, ok := registered[upper]; !ok {
+			table[upper] = types
+		}
+	}
+	return table
+}
+
+func equalArgTypes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	gxprotocol "github.com/dubbogo/gost/dubbogo/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+type overloadService struct{}
+
+func (s *overloadService) SetProxyImpl(impl gxprotocol.Invoker) {}
+
+func (s *overloadService) GetProxyImpl() gxprotocol.Invoker {
+	return nil
+}
+
+func (s *overloadService) ServiceDesc() *grpc.ServiceDesc {
+	return nil
+}
+
+func (s *overloadService) MethodMapper() map[string]string {
+	return map[string]string{
+		"SayHelloName": "sayHello",
+		"SayHelloAge":  "sayHello(java.lang.String,int)",
+	}
+}
+
+func (s *overloadService) SayHelloName(ctx context.Context, name string) (string, error) {
+	return name, nil
+}
+
+func (s *overloadService) SayHelloAge(ctx context.Context, name string, age int32) (string, error) {
+	return name, nil
+}
+
+func (s *overloadService) GetUser(id int64) (string, error) {
+	return "", nil
+}
+
+// sameNameOverloadService maps overloads to the same name, which can't be registered to proxy
+type sameNameOverloadService struct {
+	overloadService
+}
+
+func (s *sameNameOverloadService) MethodMapper() map[string]string {
+	return map[string]string{
+		"SayHelloName": "sayHello",
+		"SayHelloAge":  "sayHello",
+	}
+}
+
+func TestResolveOverload(t *testing.T) {
+	service := &overloadService{}
+	for _, c := range []struct {
+		methodName string
+		argTypes   []string
+		expected   string
+		code       codes.Code
+	}{
+		{methodName: "SayHello", argTypes: []string{"java.lang.String"}, expected: "sayHello"},
+		{methodName: "SayHello", argTypes: []string{"java.lang.String", "int"}, expected: "sayHello(java.lang.String,int)"},
+		{methodName: "SayHello", argTypes: []string{"int"}, code: codes.Unimplemented},
+		{methodName: "SayHello", code: codes.Unimplemented},
+		{methodName: "GetUser", argTypes: []string{"java.lang.String"}, expected: "GetUser"},
+		{methodName: "Unknown", expected: "Unknown"},
+	} {
+		name, err := resolveOverload(service, c.methodName, c.argTypes)
+		if c.code != codes.OK {
+			assert.Equal(t, c.code, status.Code(err))
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, name)
+	}
+
+	_, err := resolveOverload(&sameNameOverloadService{}, "SayHello", []string{"java.lang.String"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
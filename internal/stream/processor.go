@@ -19,7 +19,8 @@ package stream
 
 import (
 	"bytes"
-	"errors"
+	"context"
+	"runtime/debug"
 	"sync"
 )
 
@@ -33,12 +34,12 @@ import (
 
 import (
 	"github.com/dubbogo/triple/internal/codec"
-	"github.com/dubbogo/triple/internal/codes"
 	"github.com/dubbogo/triple/internal/message"
-	"github.com/dubbogo/triple/internal/status"
 	"github.com/dubbogo/triple/internal/tools"
+	"github.com/dubbogo/triple/pkg/codes"
 	"github.com/dubbogo/triple/pkg/common"
 	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
 )
 
 // processor is the interface, with func runRPC and close
@@ -68,6 +69,30 @@ func (p *baseProcessor) handleRPCErr(err error) {
 	p.stream.WriteCloseMsgTypeWithStatus(appStatus)
 }
 
+// handlePanic is called after panic value @e of rpc handler is recovered, it calls PanicHandler of option,
+// and write close message with codes.Internal status
+func (p *baseProcessor) handlePanic(e interface{}) {
+	stack := debug.Stack()
+	method := p.stream.getHeader().GetPath()
+	logger.Errorf("triple server handle %s panic = %v\n%s", method, e, stack)
+	CallPanicHandler(p.opt.PanicHandler, p.stream.getCtx(), method, e, stack)
+	p.handleRPCErr(status.Errorf(codes.Internal, "triple server handle %s panic: %v", method, e))
+}
+
+// CallPanicHandler calls @handler with panic value @e and @stack of rpc handler of @method if @handler isn't nil,
+// panic of @handler itself is recovered and logged
+func CallPanicHandler(handler config.PanicHandler, ctx context.Context, method string, e interface{}, stack []byte) {
+	if handler == nil {
+		return
+	}
+	defer func() {
+		if he := recover(); he != nil {
+			logger.Errorf("triple server panic handler of %s panic = %v", method, he)
+		}
+	}()
+	handler(ctx, method, e, stack)
+}
+
 // handleRPCSuccess send data and grpc success code with message
 func (p *baseProcessor) handleRPCSuccess(data []byte) {
 	p.stream.PutSend(data, message.DataMsgType)
@@ -119,21 +144,38 @@ func (p *unaryProcessor) processUnaryRPC(buf bytes.Buffer, service common.Dubbo3
 		if err := p.serializer.UnmarshalRequest(pkgData, &v); err != nil {
 			return nil, status.Errorf(codes.Internal, "Unary rpc request unmarshal error: %s", err)
 		}
+		methodName, e = resolveOverload(service, methodName, v.ArgTypes)
+		if e != nil {
+			return nil, e
+		}
+		if argTypes, ok := loadMethodArgTypes(service, methodName); ok {
+			if err := v.UnmarshalArgs(argTypes); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "Unary rpc request args unmarshal error: %s", err)
+			}
+		}
 		args := v.Val.([]interface{})
-		result := service.GetProxyImpl().Invoke(header.FieldToCtx(), invocation.NewRPCInvocation(methodName, args, nil))
+		result := service.GetProxyImpl().Invoke(p.stream.getCtx(), invocation.NewRPCInvocationWithOptions(
+			invocation.WithMethodName(methodName),
+			invocation.WithArguments(args),
+			invocation.WithParameterTypeNames(v.ArgTypes),
+		))
 		reply = result.Result()
 		err = result.Error()
-	} else if p.opt.SerializerType == common.PBSerializerName {
+	} else if p.opt.SerializerType == common.PBSerializerName || p.opt.SerializerType == common.JSONSerializerName {
 		descFunc := func(v interface{}) error {
 			if err := p.serializer.UnmarshalRequest(pkgData, v); err != nil {
 				return status.Errorf(codes.Internal, "Unary rpc request unmarshal error: %s", err)
 			}
 			return nil
 		}
-		reply, err = p.methodDesc.Handler(service, header.FieldToCtx(), descFunc, nil)
+		reply, err = p.methodDesc.Handler(service, p.stream.getCtx(), descFunc, nil)
 	}
 
 	if err != nil {
+		if appStatus, ok := status.FromError(err); ok {
+			// status error from handler contains code and details, which are sent to client as they are
+			return nil, appStatus.Err()
+		}
 		return nil, status.Errorf(codes.Internal, "Unary rpc handle error: %s", err)
 	}
 
@@ -160,7 +202,7 @@ func (p *unaryProcessor) runRPC() {
 			// in this case, server unary processor have the chance to do process and return result
 			defer func() {
 				if e := recover(); e != nil {
-					p.handleRPCErr(errors.New(e.(error).Error()))
+					p.handlePanic(e)
 				}
 			}()
 			if recvMsg.Err != nil {
@@ -206,8 +248,13 @@ func newStreamingProcessor(s *serverStream, pkgHandler common.PackageHandler, de
 
 // runRPC called by stream
 func (sp *streamingProcessor) runRPC() {
-	serverUserstream := newServerUserStream(sp.stream, sp.serializer, sp.pkgHandler)
+	serverUserstream := newServerUserStream(sp.stream.getCtx(), sp.stream, sp.serializer, sp.pkgHandler)
 	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				sp.handlePanic(e)
+			}
+		}()
 		if err := sp.streamDesc.Handler(sp.stream.getService(), serverUserstream); err != nil {
 			sp.handleRPCErr(err)
 			return
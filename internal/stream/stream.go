@@ -19,6 +19,8 @@ package stream
 
 import (
 	"bytes"
+	"context"
+	"sync"
 )
 
 import (
@@ -26,13 +28,14 @@ import (
 	logger "github.com/dubbogo/gost/dubbogo/logger"
 	h2Triple "github.com/dubbogo/net/http2/triple"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 import (
 	"github.com/dubbogo/triple/internal/message"
-	"github.com/dubbogo/triple/internal/status"
 	"github.com/dubbogo/triple/pkg/common"
 	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
 )
 
 /////////////////////////////////stream
@@ -42,9 +45,33 @@ type Stream interface {
 	// channel usage
 	PutRecv(data []byte, msgType message.MsgType)
 	PutSend(data []byte, msgType message.MsgType)
+	// PutSendUntilRecvClosed puts data to send unless recv is closed first, it returns false if data is not put
+	PutSendUntilRecvClosed(data []byte, msgType message.MsgType) bool
 	GetSend() <-chan message.Message
 	GetRecv() <-chan message.Message
 	PutSplitedDataRecv(splitedData []byte, msgType message.MsgType, handler common.PackageHandler)
+	// CloseRecv is called when remote peer has finished sending
+	CloseRecv()
+	// CloseRecvWithStatus is called when remote peer has finished sending with final status @st
+	CloseRecvWithStatus(st *status.Status)
+	// GetRecvClosed returns chan that is closed after CloseRecv is called
+	GetRecvClosed() <-chan struct{}
+	// GetRecvStatus returns final status of remote peer, it's valid after recv is closed
+	GetRecvStatus() *status.Status
+	// SetRecvTrailer stores trailer of remote peer, it must be called before recv is closed
+	SetRecvTrailer(trailer metadata.MD)
+	// GetRecvTrailer returns trailer of remote peer, it's valid after recv is closed
+	GetRecvTrailer() metadata.MD
+	// SetRecvHeader stores header of remote peer, only the first call takes effect
+	SetRecvHeader(header metadata.MD)
+	// GetRecvHeaderReady returns chan that is closed after SetRecvHeader is called
+	GetRecvHeaderReady() <-chan struct{}
+	// GetRecvHeader returns header of remote peer, it's valid after header is ready
+	GetRecvHeader() metadata.MD
+	// PutSendHeader puts header message to send
+	PutSendHeader(header metadata.MD)
+	// SetSendTrailer stores trailer that is sent with close message
+	SetSendTrailer(trailer metadata.MD)
 	Close()
 }
 
@@ -69,13 +96,31 @@ type baseStream struct {
 	// of this package
 	// when fromFrameHeaderDataSize is zero, its means we should parse header first 5byte, and then read data
 	fromFrameHeaderDataSize uint32
+	// recvClosed is closed when remote peer has finished sending
+	recvClosed    chan struct{}
+	recvCloseOnce *sync.Once
+	// recvStatus is the final status of remote peer, it's set before recvClosed is closed
+	recvStatus *status.Status
+	// recvTrailer is the trailer of remote peer, it's set before recvClosed is closed
+	recvTrailer metadata.MD
+	// recvHeader is the header of remote peer, it's set before recvHeaderReady is closed
+	recvHeader      metadata.MD
+	recvHeaderReady chan struct{}
+	recvHeaderOnce  *sync.Once
+	// sendTrailer is sent with close message
+	sendTrailer     metadata.MD
+	sendTrailerLock *sync.Mutex
 }
 
 // WriteCloseMsgTypeWithStatus put bufferMsg with status:  @st and type: ServerStreamCloseMsgType
 func (s *baseStream) WriteCloseMsgTypeWithStatus(st *status.Status) {
+	s.sendTrailerLock.Lock()
+	trailer := s.sendTrailer
+	s.sendTrailerLock.Unlock()
 	s.sendBuf.Put(message.Message{
 		Status:  st,
 		MsgType: message.ServerStreamCloseMsgType,
+		MD:      trailer,
 	})
 }
 
@@ -123,6 +168,22 @@ func (s *baseStream) PutSend(data []byte, msgType message.MsgType) {
 	})
 }
 
+// PutSendHeader put header message with @header to sendBuf
+func (s *baseStream) PutSendHeader(header metadata.MD) {
+	s.sendBuf.Put(message.Message{
+		MsgType: message.ServerHeaderMsgType,
+		MD:      header,
+	})
+}
+
+// PutSendUntilRecvClosed put message type and @data to sendBuf, unless remote peer has finished first
+func (s *baseStream) PutSendUntilRecvClosed(data []byte, msgType message.MsgType) bool {
+	return s.sendBuf.PutOrDone(message.Message{
+		Buffer:  bytes.NewBuffer(data),
+		MsgType: msgType,
+	}, s.recvClosed)
+}
+
 // getRecv get channel of receiving message
 func (s *baseStream) GetRecv() <-chan message.Message {
 	return s.recvBuf.Get()
@@ -132,6 +193,64 @@ func (s *baseStream) GetSend() <-chan message.Message {
 	return s.sendBuf.Get()
 }
 
+// CloseRecv closes recvClosed chan once, all data from remote peer must be put before it's called
+func (s *baseStream) CloseRecv() {
+	s.CloseRecvWithStatus(nil)
+}
+
+// CloseRecvWithStatus stores final status @st of remote peer, and closes recvClosed chan once
+func (s *baseStream) CloseRecvWithStatus(st *status.Status) {
+	s.recvCloseOnce.Do(func() {
+		s.recvStatus = st
+		close(s.recvClosed)
+	})
+}
+
+// GetRecvStatus returns final status of remote peer, nil means OK
+func (s *baseStream) GetRecvStatus() *status.Status {
+	return s.recvStatus
+}
+
+// SetRecvTrailer stores @trailer of remote peer
+func (s *baseStream) SetRecvTrailer(trailer metadata.MD) {
+	s.recvTrailer = trailer
+}
+
+// GetRecvTrailer returns trailer of remote peer
+func (s *baseStream) GetRecvTrailer() metadata.MD {
+	return s.recvTrailer
+}
+
+// SetRecvHeader stores @header of remote peer once, and closes recvHeaderReady chan
+func (s *baseStream) SetRecvHeader(header metadata.MD) {
+	s.recvHeaderOnce.Do(func() {
+		s.recvHeader = header
+		close(s.recvHeaderReady)
+	})
+}
+
+// GetRecvHeaderReady returns chan that is closed when header of remote peer is stored
+func (s *baseStream) GetRecvHeaderReady() <-chan struct{} {
+	return s.recvHeaderReady
+}
+
+// GetRecvHeader returns header of remote peer
+func (s *baseStream) GetRecvHeader() metadata.MD {
+	return s.recvHeader
+}
+
+// SetSendTrailer merges @trailer to trailer that is sent with close message
+func (s *baseStream) SetSendTrailer(trailer metadata.MD) {
+	s.sendTrailerLock.Lock()
+	defer s.sendTrailerLock.Unlock()
+	s.sendTrailer = metadata.Join(s.sendTrailer, trailer)
+}
+
+// GetRecvClosed get chan that is closed when remote peer has finished sending
+func (s *baseStream) GetRecvClosed() <-chan struct{} {
+	return s.recvClosed
+}
+
 func (s *baseStream) Close() {
 	s.recvBuf.Close()
 	s.sendBuf.Close()
@@ -146,6 +265,11 @@ func newBaseStream(service common.Dubbo3GrpcService) *baseStream {
 		splitBuffer: message.Message{
 			Buffer: bytes.NewBuffer(make([]byte, 0)),
 		},
+		recvClosed:      make(chan struct{}),
+		recvCloseOnce:   &sync.Once{},
+		recvHeaderReady: make(chan struct{}),
+		recvHeaderOnce:  &sync.Once{},
+		sendTrailerLock: &sync.Mutex{},
 	}
 }
 
@@ -154,20 +278,31 @@ type serverStream struct {
 	baseStream
 	processor processor
 	header    h2Triple.ProtocolHeader
+	// ctx is parsed from header, and it's canceled when stream is closed
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func (ss *serverStream) Close() {
+	// cancel ctx, as there may be streaming rpc that is waiting for ctx done
+	ss.cancel()
 	// close processor, as there may be rpc call that is waiting for process, let them returns canceled code
 	ss.processor.close()
 }
 
-func NewUnaryServerStreamWithOutDesc(header h2Triple.ProtocolHeader, url *dubboCommon.URL, service common.Dubbo3GrpcService, serializer common.Dubbo3Serializer, option *config.Option) (*serverStream, error) {
-	baseStream := newBaseStream(service)
-
-	serverStream := &serverStream{
-		baseStream: *baseStream,
+// newServerStream creates serverStream without processor
+func newServerStream(header h2Triple.ProtocolHeader, service common.Dubbo3GrpcService) *serverStream {
+	ctx, cancel := context.WithCancel(header.FieldToCtx())
+	return &serverStream{
+		baseStream: *newBaseStream(service),
 		header:     header,
+		ctx:        ctx,
+		cancel:     cancel,
 	}
+}
+
+func NewUnaryServerStreamWithOutDesc(header h2Triple.ProtocolHeader, url *dubboCommon.URL, service common.Dubbo3GrpcService, serializer common.Dubbo3Serializer, option *config.Option) (*serverStream, error) {
+	serverStream := newServerStream(header, service)
 	pkgHandler, err := common.GetPackagerHandler(url.Protocol)
 	if err != nil {
 		logger.Error("GetPkgHandler error with err = ", err)
@@ -186,12 +321,7 @@ func NewUnaryServerStreamWithOutDesc(header h2Triple.ProtocolHeader, url *dubboC
 
 // NewServerStream creates new server stream
 func NewServerStream(header h2Triple.ProtocolHeader, desc interface{}, url *dubboCommon.URL, service common.Dubbo3GrpcService, serializer common.Dubbo3Serializer, option *config.Option) (*serverStream, error) {
-	baseStream := newBaseStream(service)
-
-	serverStream := &serverStream{
-		baseStream: *baseStream,
-		header:     header,
-	}
+	serverStream := newServerStream(header, service)
 	pkgHandler, err := common.GetPackagerHandler(url.Protocol)
 	if err != nil {
 		logger.Error("GetPkgHandler error with err = ", err)
@@ -226,6 +356,11 @@ func (ss *serverStream) getHeader() h2Triple.ProtocolHeader {
 	return ss.header
 }
 
+// getCtx returns ctx of stream, which is done when stream is closed
+func (ss *serverStream) getCtx() context.Context {
+	return ss.ctx
+}
+
 // clientStream is running in client end
 type clientStream struct {
 	baseStream
@@ -240,7 +375,7 @@ func NewClientStream() *clientStream {
 	return newclientStream
 }
 
-// Close closes stream
+// Close closes stream, sendBuf is not closed, as user may still be sending, which returns after recv is closed
 func (cs *clientStream) Close() {
-	cs.baseStream.Close()
+	cs.recvBuf.Close()
 }
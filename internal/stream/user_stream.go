@@ -19,6 +19,9 @@ package stream
 
 import (
 	"context"
+	"io"
+	"sync"
+	"sync/atomic"
 )
 
 import (
@@ -29,11 +32,14 @@ import (
 
 import (
 	"github.com/dubbogo/triple/internal/message"
+	"github.com/dubbogo/triple/pkg/codes"
 	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/status"
 )
 
 // baseUserStream is the base userstream impl
 type baseUserStream struct {
+	ctx        context.Context
 	stream     Stream
 	serilizer  common.Dubbo3Serializer
 	pkgHandler common.PackageHandler
@@ -49,7 +55,7 @@ func (ss *baseUserStream) SetTrailer(metadata.MD) {
 
 }
 func (ss *baseUserStream) Context() context.Context {
-	return nil
+	return ss.ctx
 }
 func (ss *baseUserStream) SendMsg(m interface{}) error {
 	replyData, err := ss.serilizer.MarshalRequest(m)
@@ -62,10 +68,22 @@ func (ss *baseUserStream) SendMsg(m interface{}) error {
 	return nil
 }
 
+// RecvMsg blocks until a message is received, it returns io.EOF after remote peer has finished sending
 func (ss *baseUserStream) RecvMsg(m interface{}) error {
-	recvChan := ss.stream.GetRecv()
-	readBuf := <-recvChan
+	var readBuf message.Message
+	select {
+	case readBuf = <-ss.stream.GetRecv():
+	case <-ss.stream.GetRecvClosed():
+		return ss.recvClosedErr()
+	case <-ss.ctx.Done():
+		return ss.ctx.Err()
+	}
 	if readBuf.Buffer == nil {
+		select {
+		case <-ss.stream.GetRecvClosed():
+			return ss.recvClosedErr()
+		default:
+		}
 		return errors.Errorf("user stream closed!")
 	}
 	pkgData, _ := ss.pkgHandler.Frame2PkgData(readBuf.Bytes())
@@ -75,14 +93,73 @@ func (ss *baseUserStream) RecvMsg(m interface{}) error {
 	return nil
 }
 
+// recvClosedErr returns error of final status of remote peer, or io.EOF if status is OK
+func (ss *baseUserStream) recvClosedErr() error {
+	if err := ss.stream.GetRecvStatus().Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
 // serverUserStream can be throw to grpc, and let grpc use it
 type serverUserStream struct {
 	baseUserStream
+	// headerLock protects header and headerSent
+	headerLock sync.Mutex
+	header     metadata.MD
+	headerSent bool
+}
+
+// SetHeader merges @md to response header, which is sent before the first message, or when SendHeader is called
+func (ss *serverUserStream) SetHeader(md metadata.MD) error {
+	ss.headerLock.Lock()
+	defer ss.headerLock.Unlock()
+	if ss.headerSent {
+		return errors.New("triple: SetHeader called after header is sent")
+	}
+	ss.header = metadata.Join(ss.header, md)
+	return nil
 }
 
-func newServerUserStream(s Stream, serilizer common.Dubbo3Serializer, pkgHandler common.PackageHandler) *serverUserStream {
+// SendHeader sends response header merged with @md at once, it can be called at most once
+func (ss *serverUserStream) SendHeader(md metadata.MD) error {
+	ss.headerLock.Lock()
+	defer ss.headerLock.Unlock()
+	if ss.headerSent {
+		return errors.New("triple: SendHeader called after header is sent")
+	}
+	ss.header = metadata.Join(ss.header, md)
+	ss.sendHeaderLocked()
+	return nil
+}
+
+// sendHeaderLocked sends response header if it's not empty, otherwise it's sent along with the first message
+func (ss *serverUserStream) sendHeaderLocked() {
+	ss.headerSent = true
+	if len(ss.header) > 0 {
+		ss.stream.PutSendHeader(ss.header)
+	}
+}
+
+// SetTrailer merges @md to trailer, which is sent when the stream ends
+func (ss *serverUserStream) SetTrailer(md metadata.MD) {
+	ss.stream.SetSendTrailer(md)
+}
+
+// SendMsg sends response header if it's not sent, and then sends @m
+func (ss *serverUserStream) SendMsg(m interface{}) error {
+	ss.headerLock.Lock()
+	if !ss.headerSent {
+		ss.sendHeaderLocked()
+	}
+	ss.headerLock.Unlock()
+	return ss.baseUserStream.SendMsg(m)
+}
+
+func newServerUserStream(ctx context.Context, s Stream, serilizer common.Dubbo3Serializer, pkgHandler common.PackageHandler) *serverUserStream {
 	return &serverUserStream{
 		baseUserStream: baseUserStream{
+			ctx:        ctx,
 			serilizer:  serilizer,
 			pkgHandler: pkgHandler,
 			stream:     s,
@@ -93,22 +170,84 @@ func newServerUserStream(s Stream, serilizer common.Dubbo3Serializer, pkgHandler
 // clientUserStream can be throw to grpc, and let grpc use it
 type clientUserStream struct {
 	baseUserStream
+	// maxSendMsgSize limits size of serialized message to send, zero means no limit
+	maxSendMsgSize int
+	// sendClosed is set to 1 after CloseSend is called
+	sendClosed int32
 }
 
+// Header returns header of server, it blocks until header is received or the stream ends
 func (ss *clientUserStream) Header() (metadata.MD, error) {
-	return nil, nil
+	select {
+	case <-ss.stream.GetRecvHeaderReady():
+		return ss.stream.GetRecvHeader(), nil
+	case <-ss.stream.GetRecvClosed():
+	case <-ss.ctx.Done():
+		return nil, status.FromContextError(ss.ctx.Err()).Err()
+	}
+	select {
+	case <-ss.stream.GetRecvHeaderReady():
+		return ss.stream.GetRecvHeader(), nil
+	default:
+		// trailers-only response has no header
+		return nil, ss.stream.GetRecvStatus().Err()
+	}
 }
+
+// Trailer returns trailer of server, it's valid after RecvMsg returns error
 func (ss *clientUserStream) Trailer() metadata.MD {
+	select {
+	case <-ss.stream.GetRecvClosed():
+		return ss.stream.GetRecvTrailer()
+	default:
+		return nil
+	}
+}
+
+// SendMsg sends @m to server, it returns io.EOF if server has finished the stream,
+// and the final status can be got from RecvMsg
+func (ss *clientUserStream) SendMsg(m interface{}) error {
+	data, err := ss.serilizer.MarshalRequest(m)
+	if err != nil {
+		logger.Error("sen msg error with msg = ", m)
+		return err
+	}
+	return ss.SendRawMsg(data)
+}
+
+// SendRawMsg sends serialized message @data to server, it's used to resend buffered message when retrying
+func (ss *clientUserStream) SendRawMsg(data []byte) error {
+	if atomic.LoadInt32(&ss.sendClosed) == 1 {
+		return errors.New("triple: SendMsg called after CloseSend")
+	}
+	if ss.maxSendMsgSize > 0 && len(data) > ss.maxSendMsgSize {
+		return status.Errorf(codes.ResourceExhausted, "triple: trying to send message larger than max (%d vs. %d)",
+			len(data), ss.maxSendMsgSize)
+	}
+	if !ss.stream.PutSendUntilRecvClosed(ss.pkgHandler.Pkg2FrameData(data), message.DataMsgType) {
+		return io.EOF
+	}
 	return nil
 }
+
+// CloseSend closes sending direction of the stream, server receives io.EOF after all sent messages.
+// It does nothing if server has finished the stream.
 func (ss *clientUserStream) CloseSend() error {
-	// todo
+	if !atomic.CompareAndSwapInt32(&ss.sendClosed, 0, 1) {
+		return nil
+	}
+	ss.stream.PutSendUntilRecvClosed(nil, message.ServerStreamCloseMsgType)
 	return nil
 }
 
-func NewClientUserStream(s Stream, serilizer common.Dubbo3Serializer, pkgHandler common.PackageHandler) *clientUserStream {
+// NewClientUserStream returns client stream of @s, sending message larger than @maxSendMsgSize fails with
+// codes.ResourceExhausted, zero @maxSendMsgSize means no limit
+func NewClientUserStream(ctx context.Context, s Stream, serilizer common.Dubbo3Serializer, pkgHandler common.PackageHandler,
+	maxSendMsgSize int) *clientUserStream {
 	return &clientUserStream{
+		maxSendMsgSize: maxSendMsgSize,
 		baseUserStream: baseUserStream{
+			ctx:        ctx,
 			serilizer:  serilizer,
 			pkgHandler: pkgHandler,
 			stream:     s,
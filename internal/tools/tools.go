@@ -22,9 +22,9 @@ import (
 )
 
 import (
-	"github.com/dubbogo/triple/internal/codes"
-	"github.com/dubbogo/triple/internal/status"
+	"github.com/dubbogo/triple/pkg/codes"
 	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
 )
 
 // AddDefaultOption fill default options to @opt
@@ -39,11 +39,11 @@ func AddDefaultOption(opt *config.Option) *config.Option {
 func GetServiceKeyAndUpperCaseMethodNameFromPath(path string) (string, string, error) {
 	paramList := strings.Split(path, "/")
 	if len(paramList) < 3 {
-		return "", "", status.Errorf(codes.Internal, "invalid triple header path = %s", path)
+		return "", "", status.Errorf(codes.Unimplemented, "invalid triple header path = %s", path)
 	}
 	methodName := paramList[2]
 	if methodName == "" {
-		return "", "", status.Errorf(codes.Internal, "invalid method name = %s", methodName)
+		return "", "", status.Errorf(codes.Unimplemented, "invalid method name = %s", methodName)
 	}
 	methodName = strings.ToUpper(string(methodName[0])) + methodName[1:]
 	return paramList[1], methodName, nil
@@ -15,29 +15,51 @@
  * limitations under the License.
  */
 
+// Package codes defines the canonical error codes used by triple, which are the same as grpc codes.
 package codes
 
 import (
-	"fmt"
-	"strconv"
+	"google.golang.org/grpc/codes"
 )
 
-// A Code is an unsigned 32-bit error codes.
-type Code uint32
+// A Code is an unsigned 32-bit error codes, it's alias of grpc codes.Code,
+// so that codes of triple and grpc can be used interchangeably.
+type Code = codes.Code
 
 const (
 	// OK is returned on success.
-	OK Code = 0
+	OK Code = codes.OK
 
 	// Canceled indicates the operation was canceled (typically by the caller).
-	Canceled Code = 1
+	Canceled Code = codes.Canceled
 
 	// Unknown error. An example of where this error may be returned is
 	// if a Status value received from another address space belongs to
 	// an error-space that is not known in this address space. Also
 	// errors raised by APIs that do not return enough error information
 	// may be converted to this error.
-	Unknown Code = 2
+	Unknown Code = codes.Unknown
+
+	// InvalidArgument indicates client specified an invalid argument.
+	// Note that this differs from FailedPrecondition. It indicates arguments
+	// that are problematic regardless of the state of the system
+	// (e.g., a malformed file name).
+	InvalidArgument Code = codes.InvalidArgument
+
+	// DeadlineExceeded means operation expired before completion.
+	// For operations that change the state of the system, this error may be
+	// returned even if the operation has completed successfully. For
+	// example, a successful response from a server could have been delayed
+	// long enough for the deadline to expire.
+	DeadlineExceeded Code = codes.DeadlineExceeded
+
+	// NotFound means some requested entity (e.g., file or directory) was
+	// not found.
+	NotFound Code = codes.NotFound
+
+	// AlreadyExists means an attempt to create an entity failed because one
+	// already exists.
+	AlreadyExists Code = codes.AlreadyExists
 
 	// PermissionDenied indicates the caller does not have permission to
 	// execute the specified operation. It must not be used for rejections
@@ -45,77 +67,46 @@ const (
 	// instead for those errors). It must not be
 	// used if the caller cannot be identified (use Unauthenticated
 	// instead for those errors).
-	PermissionDenied Code = 7
+	PermissionDenied Code = codes.PermissionDenied
 
 	// ResourceExhausted indicates some resource has been exhausted, perhaps
 	// a per-user quota, or perhaps the entire file system is out of space.
-	ResourceExhausted Code = 8
+	ResourceExhausted Code = codes.ResourceExhausted
+
+	// FailedPrecondition indicates operation was rejected because the
+	// system is not in a state required for the operation's execution.
+	// For example, directory to be deleted may be non-empty, an rmdir
+	// operation is applied to a non-directory, etc.
+	FailedPrecondition Code = codes.FailedPrecondition
+
+	// Aborted indicates the operation was aborted, typically due to a
+	// concurrency issue like sequencer check failures, transaction aborts,
+	// etc.
+	Aborted Code = codes.Aborted
+
+	// OutOfRange means operation was attempted past the valid range.
+	// E.g., seeking or reading past end of file.
+	OutOfRange Code = codes.OutOfRange
 
 	// Unimplemented indicates operation is not implemented or not
 	// supported/enabled in this service.
-	//
-	Unimplemented Code = 12
+	Unimplemented Code = codes.Unimplemented
 
 	// Internal errors. Means some invariants expected by underlying
 	// system has been broken. If you see one of these errors,
 	// something is very broken.
-	//
-	Internal Code = 13
+	Internal Code = codes.Internal
 
 	// Unavailable indicates the service is currently unavailable.
 	// This is a most likely a transient condition and may be corrected
 	// by retrying with a backoff. Note that it is not always safe to retry
 	// non-idempotent operations.
-	//
-	// See litmus test above for deciding between FailedPrecondition,
-	// Aborted, and Unavailable.
-	//
-	Unavailable Code = 14
+	Unavailable Code = codes.Unavailable
+
+	// DataLoss indicates unrecoverable data loss or corruption.
+	DataLoss Code = codes.DataLoss
 
 	// Unauthenticated indicates the request does not have valid
 	// authentication credentials for the operation.
-	//
-	Unauthenticated Code = 16
-
-	_maxCode = 17
+	Unauthenticated Code = codes.Unauthenticated
 )
-
-var strToCode = map[string]Code{
-	`"OK"`: OK,
-	`"CANCELLED"`:/* [sic] */ Canceled,
-	`"UNKNOWN"`:            Unknown,
-	`"PERMISSION_DENIED"`:  PermissionDenied,
-	`"RESOURCE_EXHAUSTED"`: ResourceExhausted,
-	`"UNIMPLEMENTED"`:      Unimplemented,
-	`"INTERNAL"`:           Internal,
-	`"UNAVAILABLE"`:        Unavailable,
-	`"UNAUTHENTICATED"`:    Unauthenticated,
-}
-
-// UnmarshalJSON unmarshal @b into the Code.
-func (c *Code) UnmarshalJSON(b []byte) error {
-	// From json.Unmarshaler: By convention, to approximate the behavior of
-	// Unmarshal itself, Unmarshalers implement UnmarshalJSON([]byte("null")) as
-	// a no-op.
-	if string(b) == "null" {
-		return nil
-	}
-	if c == nil {
-		return fmt.Errorf("nil receiver passed to UnmarshalJSON")
-	}
-
-	if ci, err := strconv.ParseUint(string(b), 10, 32); err == nil {
-		if ci >= _maxCode {
-			return fmt.Errorf("invalid codes: %q", ci)
-		}
-
-		*c = Code(ci)
-		return nil
-	}
-
-	if jc, ok := strToCode[string(b)]; ok {
-		*c = jc
-		return nil
-	}
-	return fmt.Errorf("invalid codes: %q", string(b))
-}
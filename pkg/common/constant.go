@@ -42,4 +42,18 @@ const (
 
 	// TripleHessianWrapperSerializerName is the serializer with pb wrapped with hessian2
 	TripleHessianWrapperSerializerName = TripleSerializerName("triple-hessian-wrapper")
+
+	// JSONSerializerName is the serializer with protobuf json format for pb messages, and json for other values
+	JSONSerializerName = TripleSerializerName("json")
+
+	// FastJSONSerializerName is the serialize type of args in triple wrapper, which are serialized by fastjson of java,
+	// it's not a serializer of triple
+	FastJSONSerializerName = TripleSerializerName("fastjson")
+)
+
+// url parameters
+const (
+	// ServiceConfigKey is the key of url parameter whose value is grpc service config json for triple client,
+	// see config.ParseServiceConfig
+	ServiceConfigKey = "service.config"
 )
@@ -0,0 +1,52 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"reflect"
+	"sync"
+)
+
+// JavaArg is arg of hessian invocation with explicit java class name @Type, which is sent as its arg type
+// instead of the one mapped from go type, e.g. to call java method sayHello(Object) with a string
+type JavaArg struct {
+	Type  string
+	Value interface{}
+}
+
+var (
+	javaTypeMap  = make(map[reflect.Type]string)
+	javaTypeLock sync.RWMutex
+)
+
+// SetJavaType sets java class name of go type @typ, like "java.util.List" or "[Lcom.demo.User;",
+// which overrides the default mapping when hessian wrapper serializer sends arg types of values of @typ.
+// It also applies to elements of slices and arrays of @typ.
+func SetJavaType(typ reflect.Type, javaClassName string) {
+	javaTypeLock.Lock()
+	defer javaTypeLock.Unlock()
+	javaTypeMap[typ] = javaClassName
+}
+
+// GetJavaType returns java class name of go type @typ set by SetJavaType, it returns false if it's not set
+func GetJavaType(typ reflect.Type) (string, bool) {
+	javaTypeLock.RLock()
+	defer javaTypeLock.RUnlock()
+	javaClassName, ok := javaTypeMap[typ]
+	return javaClassName, ok
+}
@@ -76,6 +76,31 @@ type Dubbo3Serializer interface {
 	UnmarshalResponse(data []byte, v interface{}) error
 }
 
+// ContentSubtyper can be implemented by Dubbo3Serializer to advertise content-subtype of its payload,
+// the content-subtype of serializer that doesn't implement it is "proto"
+type ContentSubtyper interface {
+	ContentSubtype() string
+}
+
+// GetContentSubtype returns content-subtype of payload serialized by @serializer
+func GetContentSubtype(serializer Dubbo3Serializer) string {
+	if s, ok := serializer.(ContentSubtyper); ok {
+		return s.ContentSubtype()
+	}
+	return "proto"
+}
+
+// GetContentType returns content-type "application/grpc+{content-subtype}" of payload serialized by @serializer
+func GetContentType(serializer Dubbo3Serializer) string {
+	return "application/grpc+" + GetContentSubtype(serializer)
+}
+
+// SerializerTyper can be implemented by Dubbo3GrpcService to declare serializers of requests that it accepts,
+// server accepts serializers in its option for service that doesn't implement it
+type SerializerTyper interface {
+	SerializerTypes() []TripleSerializerName
+}
+
 type SerializerFactory func() Dubbo3Serializer
 
 var dubbo3SerializerMap = make(map[string]SerializerFactory)
@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"time"
+)
+
+// CircuitState is the state of circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed allows all invocations, and counts their failures and latency
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails all invocations fast with codes.Unavailable
+	CircuitOpen
+	// CircuitHalfOpen allows a few probe invocations, whose results decide whether to close or open the circuit again
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitStateChangeFunc is called when circuit breaker of @method of @endpoint changes state from @from to @to,
+// @method is "" for the breaker of the whole endpoint
+type CircuitStateChangeFunc func(endpoint, method string, from, to CircuitState)
+
+// CircuitBreaker is the config of client circuit breakers, there is a breaker for each endpoint,
+// and a breaker for each method of each endpoint. An invocation is allowed only if both breakers allow it.
+// Zero fields use default values.
+type CircuitBreaker struct {
+	// Window is the time window to count invocations, 10s by default
+	Window time.Duration
+	// MinRequests is the min count of invocations in window before the circuit can be opened, 20 by default
+	MinRequests int
+	// FailureRatio is the ratio of failed invocations in window that opens the circuit, 0.5 by default.
+	// Invocations failed with Unavailable, DeadlineExceeded, ResourceExhausted, Internal or Unknown are failures.
+	FailureRatio float64
+	// SlowCallDuration is the latency over which an unary invocation is slow, zero means latency isn't tracked
+	SlowCallDuration time.Duration
+	// SlowCallRatio is the ratio of slow invocations in window that opens the circuit, 0.5 by default
+	SlowCallRatio float64
+	// OpenDuration is the time circuit keeps open before it turns half-open, 5s by default
+	OpenDuration time.Duration
+	// HalfOpenProbes is the count of probe invocations in half-open state, which all have to succeed
+	// to close the circuit, 1 by default
+	HalfOpenProbes int
+	// OnStateChange is called when state of a breaker changes
+	OnStateChange CircuitStateChangeFunc
+}
+
+// WithCircuitBreaker return OptionFunction that enables client circuit breakers with @cb
+func WithCircuitBreaker(cb *CircuitBreaker) OptionFunction {
+	return func(o *Option) *Option {
+		o.CircuitBreaker = cb
+		return o
+	}
+}
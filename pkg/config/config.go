@@ -17,12 +17,74 @@
 
 package config
 
-import "github.com/dubbogo/triple/pkg/common"
+import (
+	"context"
+)
+
+import (
+	"google.golang.org/grpc"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/common"
+)
+
+// PanicHandler is called when server handler of @method panics with @value, @stack is the stack trace of the panic.
+// @ctx is the context of the invocation.
+type PanicHandler func(ctx context.Context, method string, value interface{}, stack []byte)
 
 type Option struct {
 	Timeout        uint32
 	BufferSize     uint32
 	SerializerType common.TripleSerializerName
+	// SerializerTypes are serializers of requests that server accepts, server chooses one of them for each request
+	// by its content-type, empty means only SerializerType is accepted
+	SerializerTypes []common.TripleSerializerName
+
+	// HealthCheck enables client to watch grpc.health.v1.Health service of server,
+	// client is unavailable when server reports not serving
+	HealthCheck bool
+	// HealthCheckServiceName is the service to watch, empty means the overall serving status of server
+	HealthCheckServiceName string
+
+	// ServerReflection enables server to serve grpc.reflection.v1alpha.ServerReflection
+	ServerReflection bool
+
+	// LoadReport enables server to report its cpu utilization and in-flight invocations in trailers
+	LoadReport bool
+
+	// PanicHandler is called when server handler panics, the invocation returns codes.Internal anyway
+	PanicHandler PanicHandler
+
+	// GRPCWeb enables server to accept grpc-web requests over http/1.1 and http2
+	GRPCWeb bool
+	// GRPCWebAllowedOrigins are origins allowed by CORS of grpc-web requests with credentials, "*" allows all origins
+	// without credentials, and empty means cross-origin requests are denied
+	GRPCWebAllowedOrigins []string
+
+	// HTTPGateway enables server to transcode http/json requests to unary invocations of pb services
+	HTTPGateway bool
+	// HTTPGatewayAddr is the address of separate listener of http gateway, empty means it's served on triple port
+	HTTPGatewayAddr string
+
+	// UnknownServiceHandler handles invocations of services or methods that are not registered on server,
+	// nil means these invocations return codes.Unimplemented
+	UnknownServiceHandler grpc.StreamHandler
+
+	// MethodConfigs are configs of methods, see GetMethodConfig
+	MethodConfigs map[string]*MethodConfig
+	// RetryThrottling throttles retries of client, nil means no throttling
+	RetryThrottling *RetryThrottling
+
+	// CircuitBreaker enables client circuit breakers, nil means no circuit breaker
+	CircuitBreaker *CircuitBreaker
+
+	// Balancer is the name of balancer that picks connection for each invocation of client with multiple addresses,
+	// empty means round robin
+	Balancer string
+
+	// ConnectBackoff is the backoff of client reconnecting after connection failures, nil means the default one
+	ConnectBackoff *ConnectBackoff
 }
 
 // SetEmptyFieldDefaultConfig set empty field to default config
@@ -40,6 +102,14 @@ func (o *Option) SetEmptyFieldDefaultConfig() {
 	}
 }
 
+// GetSerializerTypes returns serializers of requests that server accepts
+func (o *Option) GetSerializerTypes() []common.TripleSerializerName {
+	if len(o.SerializerTypes) > 0 {
+		return o.SerializerTypes
+	}
+	return []common.TripleSerializerName{o.SerializerType}
+}
+
 type OptionFunction func(o *Option) *Option
 
 // NewTripleOption return Triple Option with given config defined by @fs
@@ -74,3 +144,86 @@ func WithSerializerType(serializerType common.TripleSerializerName) OptionFuncti
 		return o
 	}
 }
+
+// WithSerializerTypes return OptionFunction with @serializerTypes that server accepts at the same time,
+// e.g. "protobuf" and "triple-hessian-wrapper"
+func WithSerializerTypes(serializerTypes ...common.TripleSerializerName) OptionFunction {
+	return func(o *Option) *Option {
+		o.SerializerTypes = serializerTypes
+		return o
+	}
+}
+
+// WithHealthCheck return OptionFunction that enables client health checking of @serviceName
+func WithHealthCheck(serviceName string) OptionFunction {
+	return func(o *Option) *Option {
+		o.HealthCheck = true
+		o.HealthCheckServiceName = serviceName
+		return o
+	}
+}
+
+// WithServerReflection return OptionFunction that enables server reflection service
+func WithServerReflection() OptionFunction {
+	return func(o *Option) *Option {
+		o.ServerReflection = true
+		return o
+	}
+}
+
+// WithLoadReport return OptionFunction that enables server load reporting
+func WithLoadReport() OptionFunction {
+	return func(o *Option) *Option {
+		o.LoadReport = true
+		return o
+	}
+}
+
+// WithPanicHandler return OptionFunction that calls @handler when server handler panics
+func WithPanicHandler(handler PanicHandler) OptionFunction {
+	return func(o *Option) *Option {
+		o.PanicHandler = handler
+		return o
+	}
+}
+
+// WithGRPCWeb return OptionFunction that enables server to accept grpc-web requests from browsers,
+// whose CORS requests are allowed for @allowedOrigins, or "*" for all origins without credentials.
+// Cross-origin requests are denied if @allowedOrigins is empty.
+func WithGRPCWeb(allowedOrigins ...string) OptionFunction {
+	return func(o *Option) *Option {
+		o.GRPCWeb = true
+		o.GRPCWebAllowedOrigins = allowedOrigins
+		return o
+	}
+}
+
+// WithHTTPGateway return OptionFunction that enables server to serve http/json requests, which are mapped to
+// unary methods of pb services by google.api.http annotations, or POST /{service}/{method}.
+// The gateway listens on @addr, or the same port as triple if @addr is empty.
+func WithHTTPGateway(addr string) OptionFunction {
+	return func(o *Option) *Option {
+		o.HTTPGateway = true
+		o.HTTPGatewayAddr = addr
+		return o
+	}
+}
+
+// WithUnknownServiceHandler return OptionFunction that handles invocations of unregistered services and methods
+// with @handler. @handler receives a bidirectional stream, whose messages are raw payloads in []byte:
+// RecvMsg accepts *[]byte, and SendMsg accepts []byte or *[]byte. Full method path of the invocation can be got
+// by grpc.MethodFromServerStream, and request headers by metadata.FromIncomingContext of stream context.
+func WithUnknownServiceHandler(handler grpc.StreamHandler) OptionFunction {
+	return func(o *Option) *Option {
+		o.UnknownServiceHandler = handler
+		return o
+	}
+}
+
+// WithBalancer return OptionFunction with balancer named @name, e.g. "round_robin", "random", "least_request", "p2c", "weighted"
+func WithBalancer(name string) OptionFunction {
+	return func(o *Option) *Option {
+		o.Balancer = name
+		return o
+	}
+}
@@ -19,6 +19,7 @@ package config
 
 import (
 	"testing"
+	"time"
 )
 
 import (
@@ -26,6 +27,7 @@ import (
 )
 
 import (
+	"github.com/dubbogo/triple/pkg/codes"
 	"github.com/dubbogo/triple/pkg/common"
 )
 
@@ -82,3 +84,92 @@ func TestOption_SetEmptyFieldDefaultConfig(t *testing.T) {
 	assert.Equal(t, uint32(common.DefaultHttp2ControllerReadBufferSize), opt.BufferSize)
 	assert.Equal(t, uint32(common.DefaultTimeout), opt.Timeout)
 }
+
+func TestOption_GetMethodConfig(t *testing.T) {
+	opt := NewTripleOption()
+	assert.Nil(t, opt.GetMethodConfig("/svc/Method"))
+
+	methodConfig := &MethodConfig{RetryPolicy: &RetryPolicy{MaxAttempts: 2}}
+	serviceConfig := &MethodConfig{RetryPolicy: &RetryPolicy{MaxAttempts: 3}}
+	defaultConfig := &MethodConfig{}
+	opt = NewTripleOption(
+		WithMethodConfig("/svc/Method", methodConfig),
+		WithMethodConfig("/svc", serviceConfig),
+		WithMethodConfig("", defaultConfig),
+	)
+	assert.Equal(t, methodConfig, opt.GetMethodConfig("/svc/Method"))
+	assert.Equal(t, serviceConfig, opt.GetMethodConfig("/svc/Other"))
+	assert.Equal(t, defaultConfig, opt.GetMethodConfig("/other/Method"))
+}
+
+func TestParseServiceConfig(t *testing.T) {
+	sc, err := ParseServiceConfig(`{
+		"loadBalancingPolicy": "p2c",
+		"methodConfig": [{
+			"name": [{"service": "svc", "method": "Method"}],
+			"waitForReady": true,
+			"timeout": "1.5s",
+			"maxRequestMessageBytes": 1024,
+			"maxResponseMessageBytes": 2048,
+			"retryPolicy": {
+				"maxAttempts": 3,
+				"initialBackoff": "0.1s",
+				"maxBackoff": "1s",
+				"backoffMultiplier": 2,
+				"retryableStatusCodes": ["UNAVAILABLE", 4]
+			}
+		}, {
+			"name": [{"service": "svc"}, {}],
+			"hedgingPolicy": {"maxAttempts": 2, "hedgingDelay": "0.5s", "nonFatalStatusCodes": ["UNAVAILABLE"]}
+		}],
+		"retryThrottling": {"maxTokens": 10, "tokenRatio": 0.1}
+	}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "p2c", sc.LoadBalancingPolicy)
+	assert.Equal(t, &RetryThrottling{MaxTokens: 10, TokenRatio: 0.1}, sc.RetryThrottling)
+	assert.Equal(t, &MethodConfig{
+		WaitForReady:            true,
+		Timeout:                 time.Millisecond * 1500,
+		MaxRequestMessageBytes:  1024,
+		MaxResponseMessageBytes: 2048,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:          3,
+			InitialBackoff:       time.Millisecond * 100,
+			MaxBackoff:           time.Second,
+			BackoffMultiplier:    2,
+			RetryableStatusCodes: []codes.Code{codes.Unavailable, codes.DeadlineExceeded},
+		},
+	}, sc.MethodConfigs["/svc/Method"])
+	hedgingConfig := &MethodConfig{HedgingPolicy: &HedgingPolicy{
+		MaxAttempts:         2,
+		HedgingDelay:        time.Millisecond * 500,
+		NonFatalStatusCodes: []codes.Code{codes.Unavailable},
+	}}
+	assert.Equal(t, hedgingConfig, sc.MethodConfigs["/svc"])
+	assert.Equal(t, hedgingConfig, sc.MethodConfigs[""])
+
+	opt := NewTripleOption(WithBalancer("random"), WithServiceConfig(sc))
+	assert.Equal(t, "p2c", opt.Balancer)
+	assert.Equal(t, sc.RetryThrottling, opt.RetryThrottling)
+	assert.Equal(t, hedgingConfig, opt.GetMethodConfig("/svc/Other"))
+
+	for _, js := range []string{
+		`{`,
+		`{"methodConfig": [{"name": [{"method": "Method"}]}]}`,
+		`{"methodConfig": [{"name": [{"service": "svc"}]}, {"name": [{"service": "svc"}]}]}`,
+		`{"methodConfig": [{"timeout": "1m"}]}`,
+		`{"methodConfig": [{"timeout": "1ms"}]}`,
+		`{"methodConfig": [{"timeout": "1m30s"}]}`,
+		`{"methodConfig": [{"timeout": ".5s"}]}`,
+		`{"methodConfig": [{"timeout": "-1s"}]}`,
+		`{"methodConfig": [{"maxRequestMessageBytes": 0}]}`,
+		`{"methodConfig": [{"retryPolicy": {"maxAttempts": 1}}]}`,
+		`{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "1s",
+			"backoffMultiplier": 2, "retryableStatusCodes": ["UNKNOWN_CODE"]}}]}`,
+		`{"methodConfig": [{"retryPolicy": {}, "hedgingPolicy": {}}]}`,
+		`{"retryThrottling": {"maxTokens": 0, "tokenRatio": 0.1}}`,
+	} {
+		_, err := ParseServiceConfig(js)
+		assert.NotNil(t, err, js)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"time"
+)
+
+const (
+	defaultConnectBaseDelay         = time.Second
+	defaultConnectMultiplier        = 1.6
+	defaultConnectJitter            = 0.2
+	defaultConnectMaxDelay          = 120 * time.Second
+	defaultConnectMinConnectTimeout = 20 * time.Second
+)
+
+// ConnectBackoff is grpc style backoff of client reconnecting, the n-th reconnection after failures waits
+// min(BaseDelay*Multiplier^(n-1), MaxDelay), randomized by Jitter. Zero fields use default values.
+type ConnectBackoff struct {
+	// BaseDelay is the backoff after the first failure, 1s by default
+	BaseDelay time.Duration
+	// Multiplier is multiplied to backoff after each failure, 1.6 by default
+	Multiplier float64
+	// Jitter randomizes backoff in [backoff*(1-Jitter), backoff*(1+Jitter)], 0.2 by default
+	Jitter float64
+	// MaxDelay limits the backoff, 120s by default
+	MaxDelay time.Duration
+	// MinConnectTimeout is the timeout of each connection attempt, 20s by default
+	MinConnectTimeout time.Duration
+}
+
+// GetConnectBackoff returns ConnectBackoff of option, with zero fields set to default values
+func (o *Option) GetConnectBackoff() ConnectBackoff {
+	var b ConnectBackoff
+	if o.ConnectBackoff != nil {
+		b = *o.ConnectBackoff
+	}
+	if b.BaseDelay <= 0 {
+		b.BaseDelay = defaultConnectBaseDelay
+	}
+	if b.Multiplier <= 0 {
+		b.Multiplier = defaultConnectMultiplier
+	}
+	if b.Jitter <= 0 {
+		b.Jitter = defaultConnectJitter
+	}
+	if b.MaxDelay <= 0 {
+		b.MaxDelay = defaultConnectMaxDelay
+	}
+	if b.MinConnectTimeout <= 0 {
+		b.MinConnectTimeout = defaultConnectMinConnectTimeout
+	}
+	return b
+}
+
+// WithConnectBackoff return OptionFunction with backoff @b of client reconnecting
+func WithConnectBackoff(b *ConnectBackoff) OptionFunction {
+	return func(o *Option) *Option {
+		o.ConnectBackoff = b
+		return o
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+)
+
+// MethodConfig is the config of invocations of a method
+type MethodConfig struct {
+	// Timeout is the timeout of unary invocations including all attempts, zero means no timeout besides Option.Timeout
+	Timeout time.Duration
+	// RetryPolicy is the policy to retry failed invocations, nil means no retry
+	RetryPolicy *RetryPolicy
+	// HedgingPolicy is the policy to hedge unary invocations, RetryPolicy is ignored if it's set
+	HedgingPolicy *HedgingPolicy
+	// WaitForReady makes invocations wait until connection is ready or their deadline expires,
+	// instead of failing fast with codes.Unavailable when connection is in transient failure
+	WaitForReady bool
+	// MaxRequestMessageBytes limits size of serialized request message that client sends, zero means no limit
+	MaxRequestMessageBytes int
+	// MaxResponseMessageBytes limits size of serialized response message that client receives, zero means no limit
+	MaxResponseMessageBytes int
+}
+
+// RetryPolicy is grpc style retry policy, failed attempt is retried after a random backoff in
+// [0, min(InitialBackoff*BackoffMultiplier^(n-1), MaxBackoff)) for the n-th retry
+type RetryPolicy struct {
+	// MaxAttempts is the max count of attempts including the original one, it's limited to 5
+	MaxAttempts int
+	// InitialBackoff is the max backoff before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff limits the backoff
+	MaxBackoff time.Duration
+	// BackoffMultiplier is multiplied to backoff after each retry
+	BackoffMultiplier float64
+	// RetryableStatusCodes are the status codes with which a failed attempt can be retried
+	RetryableStatusCodes []codes.Code
+}
+
+// HedgingPolicy is grpc style hedging policy, the same request is sent again every HedgingDelay
+// until a response is received or MaxAttempts attempts are sent. The first successful response wins, and
+// other attempts are canceled. Hedging should only be enabled for idempotent methods.
+type HedgingPolicy struct {
+	// MaxAttempts is the max count of attempts including the original one, it's limited to 5
+	MaxAttempts int
+	// HedgingDelay is the delay before sending next attempt, zero means all attempts are sent at once
+	HedgingDelay time.Duration
+	// NonFatalStatusCodes are the status codes with which a failed attempt doesn't fail the invocation,
+	// and next attempt is sent at once. Failed attempt with other status codes cancels all attempts.
+	NonFatalStatusCodes []codes.Code
+}
+
+// RetryThrottling limits retries and hedges of client with a token bucket, to avoid retry storms when servers are overloaded.
+// Each failed attempt with retryable or non-fatal status takes one token, and each successful invocation adds TokenRatio tokens.
+// Retry or hedge is only allowed when there are more than MaxTokens/2 tokens.
+type RetryThrottling struct {
+	MaxTokens  float64 `json:"maxTokens"`
+	TokenRatio float64 `json:"tokenRatio"`
+}
+
+// GetMethodConfig returns config of method with invocation @path "/service/method".
+// Config of the method is preferred, then config of the service with key "/service", then the default one with key "".
+func (o *Option) GetMethodConfig(path string) *MethodConfig {
+	if len(o.MethodConfigs) == 0 {
+		return nil
+	}
+	if mc, ok := o.MethodConfigs[path]; ok {
+		return mc
+	}
+	if i := strings.LastIndex(path, "/"); i > 0 {
+		if mc, ok := o.MethodConfigs[path[:i]]; ok {
+			return mc
+		}
+	}
+	return o.MethodConfigs[""]
+}
+
+// WithMethodConfig return OptionFunction with config @mc of @name, which is "/service/method" for a method,
+// "/service" for all methods of a service, and "" for all methods
+func WithMethodConfig(name string, mc *MethodConfig) OptionFunction {
+	return func(o *Option) *Option {
+		if o.MethodConfigs == nil {
+			o.MethodConfigs = make(map[string]*MethodConfig)
+		}
+		o.MethodConfigs[name] = mc
+		return o
+	}
+}
+
+// WithRetryThrottling return OptionFunction that throttles retries with @maxTokens and @tokenRatio
+func WithRetryThrottling(maxTokens, tokenRatio float64) OptionFunction {
+	return func(o *Option) *Option {
+		o.RetryThrottling = &RetryThrottling{
+			MaxTokens:  maxTokens,
+			TokenRatio: tokenRatio,
+		}
+		return o
+	}
+}
@@ -0,0 +1,221 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+)
+
+// ServiceConfig is the client config parsed from grpc service config json, see ParseServiceConfig
+type ServiceConfig struct {
+	// LoadBalancingPolicy is the name of balancer, empty means not set
+	LoadBalancingPolicy string
+	// MethodConfigs are configs of methods, keyed by name like WithMethodConfig
+	MethodConfigs map[string]*MethodConfig
+	// RetryThrottling throttles retries of client, nil means not set
+	RetryThrottling *RetryThrottling
+}
+
+// jsonServiceConfig is the json format of grpc service config
+type jsonServiceConfig struct {
+	LoadBalancingPolicy string              `json:"loadBalancingPolicy"`
+	MethodConfig        []*jsonMethodConfig `json:"methodConfig"`
+	RetryThrottling     *RetryThrottling    `json:"retryThrottling"`
+}
+
+type jsonName struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+}
+
+type jsonMethodConfig struct {
+	Name                    []jsonName         `json:"name"`
+	WaitForReady            *bool              `json:"waitForReady"`
+	Timeout                 *jsonDuration      `json:"timeout"`
+	MaxRequestMessageBytes  *int               `json:"maxRequestMessageBytes"`
+	MaxResponseMessageBytes *int               `json:"maxResponseMessageBytes"`
+	RetryPolicy             *jsonRetryPolicy   `json:"retryPolicy"`
+	HedgingPolicy           *jsonHedgingPolicy `json:"hedgingPolicy"`
+}
+
+type jsonRetryPolicy struct {
+	MaxAttempts          int          `json:"maxAttempts"`
+	InitialBackoff       jsonDuration `json:"initialBackoff"`
+	MaxBackoff           jsonDuration `json:"maxBackoff"`
+	BackoffMultiplier    float64      `json:"backoffMultiplier"`
+	RetryableStatusCodes []codes.Code `json:"retryableStatusCodes"`
+}
+
+type jsonHedgingPolicy struct {
+	MaxAttempts         int          `json:"maxAttempts"`
+	HedgingDelay        jsonDuration `json:"hedgingDelay"`
+	NonFatalStatusCodes []codes.Code `json:"nonFatalStatusCodes"`
+}
+
+// jsonDurationPattern matches duration in json format of protobuf, which is seconds with optional fraction
+var jsonDurationPattern = regexp.MustCompile(`^-?\d+(\.\d+)?s$`)
+
+// jsonDuration is duration in json format of protobuf, e.g. "1.5s"
+type jsonDuration time.Duration
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return perrors.Errorf("invalid duration %s", data)
+	}
+	if !jsonDurationPattern.MatchString(s) {
+		return perrors.Errorf("invalid duration %q, it must be seconds with optional fraction ending with \"s\"", s)
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil || duration < 0 {
+		return perrors.Errorf("invalid duration %q", s)
+	}
+	*d = jsonDuration(duration)
+	return nil
+}
+
+// ParseServiceConfig parses grpc service config json @js, which supports loadBalancingPolicy, retryThrottling, and
+// timeout, waitForReady, maxRequestMessageBytes, maxResponseMessageBytes, retryPolicy and hedgingPolicy of methodConfig.
+// e.g. {"methodConfig": [{"name": [{"service": "pkg.Greeter", "method": "SayHello"}], "timeout": "1.5s"}]}
+func ParseServiceConfig(js string) (*ServiceConfig, error) {
+	var jsc jsonServiceConfig
+	if err := json.Unmarshal([]byte(js), &jsc); err != nil {
+		return nil, perrors.Errorf("parse service config error = %v", err)
+	}
+	if throttling := jsc.RetryThrottling; throttling != nil {
+		if throttling.MaxTokens <= 0 || throttling.MaxTokens > 1000 || throttling.TokenRatio <= 0 {
+			return nil, perrors.Errorf("invalid retryThrottling %+v", *throttling)
+		}
+	}
+
+	sc := &ServiceConfig{
+		LoadBalancingPolicy: jsc.LoadBalancingPolicy,
+		MethodConfigs:       make(map[string]*MethodConfig),
+		RetryThrottling:     jsc.RetryThrottling,
+	}
+	for _, jmc := range jsc.MethodConfig {
+		if jmc == nil {
+			continue
+		}
+		mc, err := jmc.toMethodConfig()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range jmc.Name {
+			key, err := name.key()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := sc.MethodConfigs[key]; ok {
+				return nil, perrors.Errorf("duplicate method config of name %+v", name)
+			}
+			sc.MethodConfigs[key] = mc
+		}
+	}
+	return sc, nil
+}
+
+// key returns key of method config with the name, which is "/service/method", "/service" or ""
+func (n jsonName) key() (string, error) {
+	if n.Service == "" {
+		if n.Method != "" {
+			return "", perrors.Errorf("method %s of method config has no service", n.Method)
+		}
+		return "", nil
+	}
+	if n.Method == "" {
+		return "/" + n.Service, nil
+	}
+	return "/" + n.Service + "/" + n.Method, nil
+}
+
+func (jmc *jsonMethodConfig) toMethodConfig() (*MethodConfig, error) {
+	mc := &MethodConfig{}
+	if jmc.WaitForReady != nil {
+		mc.WaitForReady = *jmc.WaitForReady
+	}
+	if jmc.Timeout != nil {
+		mc.Timeout = time.Duration(*jmc.Timeout)
+	}
+	if jmc.MaxRequestMessageBytes != nil {
+		if *jmc.MaxRequestMessageBytes <= 0 {
+			return nil, perrors.Errorf("invalid maxRequestMessageBytes %d", *jmc.MaxRequestMessageBytes)
+		}
+		mc.MaxRequestMessageBytes = *jmc.MaxRequestMessageBytes
+	}
+	if jmc.MaxResponseMessageBytes != nil {
+		if *jmc.MaxResponseMessageBytes <= 0 {
+			return nil, perrors.Errorf("invalid maxResponseMessageBytes %d", *jmc.MaxResponseMessageBytes)
+		}
+		mc.MaxResponseMessageBytes = *jmc.MaxResponseMessageBytes
+	}
+	if jmc.RetryPolicy != nil && jmc.HedgingPolicy != nil {
+		return nil, perrors.New("method config can't have both retryPolicy and hedgingPolicy")
+	}
+	if rp := jmc.RetryPolicy; rp != nil {
+		if rp.MaxAttempts < 2 || rp.InitialBackoff <= 0 || rp.MaxBackoff <= 0 || rp.BackoffMultiplier <= 0 ||
+			len(rp.RetryableStatusCodes) == 0 {
+			return nil, perrors.Errorf("invalid retryPolicy %+v", *rp)
+		}
+		mc.RetryPolicy = &RetryPolicy{
+			MaxAttempts:          rp.MaxAttempts,
+			InitialBackoff:       time.Duration(rp.InitialBackoff),
+			MaxBackoff:           time.Duration(rp.MaxBackoff),
+			BackoffMultiplier:    rp.BackoffMultiplier,
+			RetryableStatusCodes: rp.RetryableStatusCodes,
+		}
+	}
+	if hp := jmc.HedgingPolicy; hp != nil {
+		if hp.MaxAttempts < 2 {
+			return nil, perrors.Errorf("invalid hedgingPolicy %+v", *hp)
+		}
+		mc.HedgingPolicy = &HedgingPolicy{
+			MaxAttempts:         hp.MaxAttempts,
+			HedgingDelay:        time.Duration(hp.HedgingDelay),
+			NonFatalStatusCodes: hp.NonFatalStatusCodes,
+		}
+	}
+	return mc, nil
+}
+
+// WithServiceConfig return OptionFunction with service config @sc, method configs of @sc replace the ones with the same
+// name, and retry throttling and balancer are replaced if they are set in @sc
+func WithServiceConfig(sc *ServiceConfig) OptionFunction {
+	return func(o *Option) *Option {
+		for name, mc := range sc.MethodConfigs {
+			o = WithMethodConfig(name, mc)(o)
+		}
+		if sc.RetryThrottling != nil {
+			o.RetryThrottling = sc.RetryThrottling
+		}
+		if sc.LoadBalancingPolicy != "" {
+			o.Balancer = sc.LoadBalancingPolicy
+		}
+		return o
+	}
+}
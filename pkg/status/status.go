@@ -15,9 +15,12 @@
  * limitations under the License.
  */
 
+// Package status implements errors returned by triple. These errors are serialized and transmitted on the wire
+// between server and client, and they can be read by google.golang.org/grpc/status too.
 package status
 
 import (
+	"context"
 	"errors"
 	"fmt"
 )
@@ -26,10 +29,11 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	spb "google.golang.org/genproto/googleapis/rpc/status"
+	grpcstatus "google.golang.org/grpc/status"
 )
 
 import (
-	"github.com/dubbogo/triple/internal/codes"
+	"github.com/dubbogo/triple/pkg/codes"
 )
 
 // Status represents an RPC status codes, message, and details.  It is immutable
@@ -38,6 +42,8 @@ type Status struct {
 	s *spb.Status
 }
 
+// FromError returns a Status representing @err if it was produced by this package or grpc status package,
+// otherwise ok is false and a Status is returned with codes.Unknown and the original error message.
 func FromError(err error) (s *Status, ok bool) {
 	if err == nil {
 		return nil, true
@@ -45,9 +51,40 @@ func FromError(err error) (s *Status, ok bool) {
 	if se, ok := err.(*Error); ok {
 		return &Status{s: se.e}, true
 	}
+	if gs, ok := err.(interface {
+		GRPCStatus() *grpcstatus.Status
+	}); ok && gs.GRPCStatus() != nil {
+		return FromProto(gs.GRPCStatus().Proto()), true
+	}
 	return New(codes.Unknown, err.Error()), false
 }
 
+// Convert is a convenience function which removes the need to handle the boolean return value from FromError.
+func Convert(err error) *Status {
+	s, _ := FromError(err)
+	return s
+}
+
+// Code returns the codes of @err if it is a status error, codes.OK if @err is nil, or codes.Unknown otherwise.
+func Code(err error) codes.Code {
+	return Convert(err).Code()
+}
+
+// FromContextError converts a context error into a Status. It returns a Status with codes.OK if @err is nil,
+// or a Status with codes.Unknown if @err is non-nil and not a context error.
+func FromContextError(err error) *Status {
+	switch err {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return New(codes.DeadlineExceeded, err.Error())
+	case context.Canceled:
+		return New(codes.Canceled, err.Error())
+	default:
+		return New(codes.Unknown, err.Error())
+	}
+}
+
 // New returns a Status representing c and msg.
 func New(c codes.Code, msg string) *Status {
 	return &Status{s: &spb.Status{Code: int32(c), Message: msg}}
@@ -151,6 +188,11 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("rpc error: codes = %+v desc = %v", codes.Code(e.e.GetCode()), e.e.GetMessage())
 }
 
+// GRPCStatus returns grpc Status of @e, so that google.golang.org/grpc/status.FromError can read it.
+func (e *Error) GRPCStatus() *grpcstatus.Status {
+	return grpcstatus.FromProto(e.e)
+}
+
 // Is implements future error.Is functionality.
 // A Error is equivalent if the codes and message are identical.
 func (e *Error) Is(target error) bool {
@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+)
+
+func TestFromError(t *testing.T) {
+	st, ok := FromError(nil)
+	assert.True(t, ok)
+	assert.Equal(t, codes.OK, st.Code())
+
+	st, ok = FromError(Err(codes.NotFound, "not found"))
+	assert.True(t, ok)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "not found", st.Message())
+
+	st, ok = FromError(grpcstatus.Error(codes.Unavailable, "unavailable"))
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "unavailable", st.Message())
+
+	st, ok = FromError(errors.New("plain error"))
+	assert.False(t, ok)
+	assert.Equal(t, codes.Unknown, st.Code())
+	assert.Equal(t, "plain error", st.Message())
+
+	assert.Equal(t, codes.Canceled, FromContextError(context.Canceled).Code())
+	assert.Equal(t, codes.DeadlineExceeded, FromContextError(context.DeadlineExceeded).Code())
+}
+
+func TestGRPCStatus(t *testing.T) {
+	st, err := New(codes.InvalidArgument, "bad request").WithDetails(&errdetails.ErrorInfo{Reason: "test"})
+	assert.Nil(t, err)
+
+	grpcSt, ok := grpcstatus.FromError(st.Err())
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, grpcSt.Code())
+	assert.Equal(t, "bad request", grpcSt.Message())
+	assert.Equal(t, "test", grpcSt.Details()[0].(*errdetails.ErrorInfo).GetReason())
+	assert.Equal(t, codes.InvalidArgument, Code(st.Err()))
+}
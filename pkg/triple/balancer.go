@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+const (
+	// RoundRobinBalancerName picks connections in turn, it's the default balancer
+	RoundRobinBalancerName = "round_robin"
+	// RandomBalancerName picks connections randomly
+	RandomBalancerName = "random"
+	// LeastRequestBalancerName picks the connection with the least in-flight invocations
+	LeastRequestBalancerName = "least_request"
+	// P2CBalancerName picks two connections randomly, and chooses the one with less in-flight invocations
+	P2CBalancerName = "p2c"
+	// WeightedBalancerName picks connections randomly, weighted by load that servers report in trailers
+	WeightedBalancerName = "weighted"
+)
+
+// Balancer picks connection for each invocation of client with multiple addresses
+type Balancer interface {
+	// Pick returns one of @conns for invocation with @path, @conns are available and not empty
+	Pick(path string, conns []*TripleClient) *TripleClient
+}
+
+// BalancerBuilder builds a Balancer for a client
+type BalancerBuilder func() Balancer
+
+var (
+	balancersLock sync.RWMutex
+	balancers     = map[string]BalancerBuilder{
+		RoundRobinBalancerName:   func() Balancer { return &roundRobinBalancer{} },
+		RandomBalancerName:       func() Balancer { return randomBalancer{} },
+		LeastRequestBalancerName: func() Balancer { return leastRequestBalancer{} },
+		P2CBalancerName:          func() Balancer { return p2cBalancer{} },
+		WeightedBalancerName:     newWeightedBalancer,
+	}
+)
+
+// RegisterBalancer registers @builder of balancer with @name, it replaces the registered one
+func RegisterBalancer(name string, builder BalancerBuilder) {
+	balancersLock.Lock()
+	defer balancersLock.Unlock()
+	balancers[name] = builder
+}
+
+// buildBalancer builds balancer with @name, empty @name means round robin
+func buildBalancer(name string) (Balancer, error) {
+	if name == "" {
+		name = RoundRobinBalancerName
+	}
+	balancersLock.RLock()
+	builder, ok := balancers[name]
+	balancersLock.RUnlock()
+	if !ok {
+		return nil, perrors.Errorf("triple balancer %s is not registered", name)
+	}
+	return builder(), nil
+}
+
+type roundRobinBalancer struct {
+	next uint32
+}
+
+func (b *roundRobinBalancer) Pick(path string, conns []*TripleClient) *TripleClient {
+	return conns[(atomic.AddUint32(&b.next, 1)-1)%uint32(len(conns))]
+}
+
+type randomBalancer struct{}
+
+func (randomBalancer) Pick(path string, conns []*TripleClient) *TripleClient {
+	return conns[rand.Intn(len(conns))]
+}
+
+type leastRequestBalancer struct{}
+
+// Pick returns the connection with the least in-flight invocations, ties are broken by starting from a random one
+func (leastRequestBalancer) Pick(path string, conns []*TripleClient) *TripleClient {
+	start := rand.Intn(len(conns))
+	picked := conns[start]
+	for i := 1; i < len(conns); i++ {
+		if c := conns[(start+i)%len(conns)]; c.Inflight() < picked.Inflight() {
+			picked = c
+		}
+	}
+	return picked
+}
+
+type p2cBalancer struct{}
+
+func (p2cBalancer) Pick(path string, conns []*TripleClient) *TripleClient {
+	if len(conns) == 1 {
+		return conns[0]
+	}
+	i := rand.Intn(len(conns))
+	j := rand.Intn(len(conns) - 1)
+	if j >= i {
+		j++
+	}
+	if conns[j].Inflight() < conns[i].Inflight() {
+		return conns[j]
+	}
+	return conns[i]
+}
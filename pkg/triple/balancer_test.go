@@ -0,0 +1,177 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+func TestParseTarget(t *testing.T) {
+	target, err := ParseTarget("static:///a:20000,b:20000")
+	assert.Nil(t, err)
+	assert.Equal(t, &Target{Scheme: "static", Endpoint: "a:20000,b:20000"}, target)
+
+	target, err = ParseTarget("file:///etc/triple/endpoints.json")
+	assert.Nil(t, err)
+	assert.Equal(t, &Target{Scheme: "file", Endpoint: "etc/triple/endpoints.json"}, target)
+
+	_, err = ParseTarget("a:20000")
+	assert.NotNil(t, err)
+	_, err = buildResolver(&Target{Scheme: "unknown"})
+	assert.NotNil(t, err)
+}
+
+func TestBalancers(t *testing.T) {
+	idle := &TripleClient{h2Controller: &H2Controller{}}
+	busy := &TripleClient{h2Controller: &H2Controller{inflight: 10}}
+	conns := []*TripleClient{busy, idle}
+
+	rr, err := buildBalancer("")
+	assert.Nil(t, err)
+	assert.Equal(t, busy, rr.Pick("", conns))
+	assert.Equal(t, idle, rr.Pick("", conns))
+	assert.Equal(t, busy, rr.Pick("", conns))
+
+	for _, name := range []string{LeastRequestBalancerName, P2CBalancerName} {
+		b, err := buildBalancer(name)
+		assert.Nil(t, err)
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, idle, b.Pick("", conns))
+		}
+	}
+
+	random, err := buildBalancer(RandomBalancerName)
+	assert.Nil(t, err)
+	assert.Equal(t, idle, random.Pick("", []*TripleClient{idle}))
+
+	_, err = buildBalancer("unknown")
+	assert.NotNil(t, err)
+}
+
+// startFlakyServer starts server with flakyService @service
+func startFlakyServer(t *testing.T, service *flakyService) (*TripleServer, string) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(flakyServiceName, service)
+	server, url := startTestServer(t, serviceMap, nil)
+	return server, url.Location
+}
+
+func TestClientWithTarget(t *testing.T) {
+	serviceA, serviceB := newFlakyService(0, 0), newFlakyService(0, 0)
+	serverA, addrA := startFlakyServer(t, serviceA)
+	defer serverA.Stop()
+	serverB, addrB := startFlakyServer(t, serviceB)
+	defer serverB.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	callPath := "/" + flakyServiceName + "/Call"
+
+	// invocations are balanced between addresses
+	client, err := NewTripleClientWithTarget("static:///"+addrA+","+addrB, newTestURL(t), testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, client.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{}))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&serviceA.calls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&serviceB.calls))
+	assert.True(t, client.IsAvailable())
+	assert.Equal(t, int64(0), client.Inflight())
+
+	// addresses in file are updated
+	dir, err := ioutil.TempDir("", "triple")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "endpoints.json")
+	assert.Nil(t, ioutil.WriteFile(file, []byte(`{"addresses": ["`+addrA+`"]}`), 0644))
+	fileClient, err := NewTripleClientWithTarget("file://"+file, newTestURL(t), testStubImpl{}, config.NewTripleOption(
+		config.WithBalancer(LeastRequestBalancerName),
+	))
+	assert.Nil(t, err)
+	defer fileClient.Close()
+	assert.Equal(t, 1, len(fileClient.conns))
+	connA := fileClient.conns[0]
+	assert.Equal(t, addrA, connA.Address())
+
+	assert.Nil(t, ioutil.WriteFile(file, []byte(`{"addresses": ["`+addrB+`"]}`), 0644))
+	assert.Nil(t, fileClient.resolver.(*fileResolver).refresh())
+	assert.Equal(t, 1, len(fileClient.conns))
+	assert.Equal(t, addrB, fileClient.conns[0].Address())
+	serviceB.reset(0, 0)
+	assert.Nil(t, fileClient.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{}))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&serviceB.calls))
+	// removed connection is closed as it's idle
+	time.Sleep(time.Millisecond * 100)
+	assert.False(t, connA.IsAvailable())
+
+	// no address
+	assert.Nil(t, ioutil.WriteFile(file, []byte(`{"addresses": []}`), 0644))
+	assert.Nil(t, fileClient.resolver.(*fileResolver).refresh())
+	err = fileClient.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestHedgingWithTarget(t *testing.T) {
+	serviceA, serviceB := newFlakyService(0, 0), newFlakyService(0, 0)
+	serverA, addrA := startFlakyServer(t, serviceA)
+	defer serverA.Stop()
+	serverB, addrB := startFlakyServer(t, serviceB)
+	defer serverB.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	// round robin picks the first address after sorting, which is slow
+	slow, fast := serviceA, serviceB
+	if addrB < addrA {
+		slow, fast = serviceB, serviceA
+	}
+	slow.reset(0, time.Second)
+	target := "static:///" + addrA + "," + addrB
+	client, err := NewTripleClientWithTarget(target, newTestURL(t), testStubImpl{}, config.NewTripleOption(
+		config.WithMethodConfig("", &config.MethodConfig{
+			HedgingPolicy: &config.HedgingPolicy{MaxAttempts: 2, HedgingDelay: time.Millisecond * 50},
+		}),
+	))
+	assert.Nil(t, err)
+	defer client.Close()
+
+	start := time.Now()
+	assert.Nil(t, client.Request(ctx, "/"+flakyServiceName+"/Call", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{}))
+	assert.True(t, time.Since(start) < time.Millisecond*500)
+	// the hedge goes to the other address
+	assert.Equal(t, int32(1), atomic.LoadInt32(&slow.calls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fast.calls))
+}
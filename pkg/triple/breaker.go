@@ -0,0 +1,311 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+const (
+	defaultBreakerWindow         = time.Second * 10
+	defaultBreakerMinRequests    = 20
+	defaultBreakerFailureRatio   = 0.5
+	defaultBreakerSlowCallRatio  = 0.5
+	defaultBreakerOpenDuration   = time.Second * 5
+	defaultBreakerHalfOpenProbes = 1
+
+	// breakerBuckets is the count of buckets in window, old buckets slide out of window one by one
+	breakerBuckets = 10
+)
+
+// breakerFailureCodes are status codes of invocations that count as failures, which means endpoint is unhealthy
+var breakerFailureCodes = []codes.Code{
+	codes.Unavailable,
+	codes.DeadlineExceeded,
+	codes.ResourceExhausted,
+	codes.Internal,
+	codes.Unknown,
+}
+
+// breakerBucket counts invocations of a bucket of window
+type breakerBucket struct {
+	id       int64
+	total    int
+	failures int
+	slow     int
+}
+
+// circuitBreaker is the breaker of a method of an endpoint, or the whole endpoint
+type circuitBreaker struct {
+	cfg      config.CircuitBreaker
+	endpoint string
+	method   string
+
+	mu             sync.Mutex
+	state          config.CircuitState
+	openedAt       time.Time
+	buckets        [breakerBuckets]breakerBucket
+	bucketDuration time.Duration
+	// probes is the count of probe invocations that are allowed in half-open state
+	probes int
+	// probeSuccesses is the count of succeeded probe invocations in half-open state
+	probeSuccesses int
+
+	// now returns current time, which is replaced in tests
+	now func() time.Time
+}
+
+// newCircuitBreaker returns closed circuitBreaker of @method of @endpoint, with @cfg whose zero fields are defaulted
+func newCircuitBreaker(cfg *config.CircuitBreaker, endpoint, method string) *circuitBreaker {
+	cb := &circuitBreaker{
+		cfg:      *cfg,
+		endpoint: endpoint,
+		method:   method,
+		now:      time.Now,
+	}
+	if cb.cfg.Window <= 0 {
+		cb.cfg.Window = defaultBreakerWindow
+	}
+	if cb.cfg.MinRequests <= 0 {
+		cb.cfg.MinRequests = defaultBreakerMinRequests
+	}
+	if cb.cfg.FailureRatio <= 0 {
+		cb.cfg.FailureRatio = defaultBreakerFailureRatio
+	}
+	if cb.cfg.SlowCallRatio <= 0 {
+		cb.cfg.SlowCallRatio = defaultBreakerSlowCallRatio
+	}
+	if cb.cfg.OpenDuration <= 0 {
+		cb.cfg.OpenDuration = defaultBreakerOpenDuration
+	}
+	if cb.cfg.HalfOpenProbes <= 0 {
+		cb.cfg.HalfOpenProbes = defaultBreakerHalfOpenProbes
+	}
+	cb.bucketDuration = cb.cfg.Window / breakerBuckets
+	if cb.bucketDuration <= 0 {
+		cb.bucketDuration = 1
+	}
+	return cb
+}
+
+// allow returns if an invocation is allowed, and if it's a probe invocation in half-open state
+func (cb *circuitBreaker) allow() (ok bool, probe bool) {
+	cb.mu.Lock()
+	var notify func()
+	defer func() {
+		cb.mu.Unlock()
+		if notify != nil {
+			notify()
+		}
+	}()
+
+	switch cb.state {
+	case config.CircuitClosed:
+		return true, false
+	case config.CircuitOpen:
+		if cb.now().Sub(cb.openedAt) < cb.cfg.OpenDuration {
+			return false, false
+		}
+		notify = cb.setState(config.CircuitHalfOpen)
+	}
+	if cb.probes >= cb.cfg.HalfOpenProbes {
+		return false, false
+	}
+	cb.probes++
+	return true, true
+}
+
+// release gives back probe permit of invocation that isn't done, e.g. the other breaker doesn't allow it
+func (cb *circuitBreaker) release(probe bool) {
+	if !probe {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == config.CircuitHalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+}
+
+// record counts invocation that returns @err with @latency, zero @latency means latency isn't tracked.
+// @probe is what allow returns for the invocation.
+func (cb *circuitBreaker) record(probe bool, err error, latency time.Duration) {
+	code := status.Code(err)
+	if code == codes.Canceled {
+		// canceled by client, e.g. a hedged attempt loses, which says nothing about the endpoint
+		cb.release(probe)
+		return
+	}
+	failed := containsCode(code, breakerFailureCodes)
+	slow := cb.cfg.SlowCallDuration > 0 && latency > cb.cfg.SlowCallDuration
+
+	cb.mu.Lock()
+	var notify func()
+	defer func() {
+		cb.mu.Unlock()
+		if notify != nil {
+			notify()
+		}
+	}()
+
+	switch cb.state {
+	case config.CircuitHalfOpen:
+		if !probe {
+			// invocation allowed before the circuit is opened
+			return
+		}
+		if failed || slow {
+			notify = cb.setState(config.CircuitOpen)
+			return
+		}
+		cb.probeSuccesses++
+		if cb.probeSuccesses >= cb.cfg.HalfOpenProbes {
+			notify = cb.setState(config.CircuitClosed)
+		}
+	case config.CircuitClosed:
+		now := cb.now()
+		b := cb.bucket(now)
+		b.total++
+		if failed {
+			b.failures++
+		}
+		if slow {
+			b.slow++
+		}
+		total, failures, slows := cb.counts(now)
+		if total < cb.cfg.MinRequests {
+			return
+		}
+		if float64(failures)/float64(total) >= cb.cfg.FailureRatio ||
+			(cb.cfg.SlowCallDuration > 0 && float64(slows)/float64(total) >= cb.cfg.SlowCallRatio) {
+			notify = cb.setState(config.CircuitOpen)
+		}
+	}
+}
+
+// isOpen returns true if the circuit is open and probes are not allowed yet
+func (cb *circuitBreaker) isOpen() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state == config.CircuitOpen && cb.now().Sub(cb.openedAt) < cb.cfg.OpenDuration
+}
+
+// setState changes state of the breaker to @to and resets counters, it must be called with lock.
+// It returns the func to notify the change, which must be called without lock.
+func (cb *circuitBreaker) setState(to config.CircuitState) func() {
+	from := cb.state
+	cb.state = to
+	cb.probes = 0
+	cb.probeSuccesses = 0
+	switch to {
+	case config.CircuitOpen:
+		cb.openedAt = cb.now()
+	case config.CircuitClosed:
+		cb.buckets = [breakerBuckets]breakerBucket{}
+	}
+	if cb.cfg.OnStateChange == nil {
+		return nil
+	}
+	return func() {
+		cb.cfg.OnStateChange(cb.endpoint, cb.method, from, to)
+	}
+}
+
+// bucket returns bucket of @now, which is reset if it's out of window
+func (cb *circuitBreaker) bucket(now time.Time) *breakerBucket {
+	id := now.UnixNano() / int64(cb.bucketDuration)
+	b := &cb.buckets[id%breakerBuckets]
+	if b.id != id {
+		*b = breakerBucket{id: id}
+	}
+	return b
+}
+
+// counts returns counts of invocations in window of @now
+func (cb *circuitBreaker) counts(now time.Time) (total, failures, slow int) {
+	id := now.UnixNano() / int64(cb.bucketDuration)
+	for _, b := range cb.buckets {
+		if id-b.id < breakerBuckets {
+			total += b.total
+			failures += b.failures
+			slow += b.slow
+		}
+	}
+	return
+}
+
+// endpointBreakers are circuit breakers of an endpoint and its methods
+type endpointBreakers struct {
+	cfg      *config.CircuitBreaker
+	endpoint *circuitBreaker
+	methods  sync.Map
+}
+
+// newEndpointBreakers returns breakers of @endpoint with @cfg, or nil if @cfg is nil
+func newEndpointBreakers(cfg *config.CircuitBreaker, endpoint string) *endpointBreakers {
+	if cfg == nil {
+		return nil
+	}
+	return &endpointBreakers{
+		cfg:      cfg,
+		endpoint: newCircuitBreaker(cfg, endpoint, ""),
+	}
+}
+
+// method returns breaker of method with @path
+func (eb *endpointBreakers) method(path string) *circuitBreaker {
+	if cb, ok := eb.methods.Load(path); ok {
+		return cb.(*circuitBreaker)
+	}
+	cb, _ := eb.methods.LoadOrStore(path, newCircuitBreaker(eb.cfg, eb.endpoint.endpoint, path))
+	return cb.(*circuitBreaker)
+}
+
+// allow returns func to record result of invocation with @path if it's allowed,
+// or Unavailable error if the circuit of endpoint or method is open
+func (eb *endpointBreakers) allow(path string) (func(err error, latency time.Duration), error) {
+	if eb == nil {
+		return func(error, time.Duration) {}, nil
+	}
+	endpointOK, endpointProbe := eb.endpoint.allow()
+	if !endpointOK {
+		return nil, status.Errorf(codes.Unavailable, "circuit breaker of endpoint %s is open", eb.endpoint.endpoint)
+	}
+	methodBreaker := eb.method(path)
+	methodOK, methodProbe := methodBreaker.allow()
+	if !methodOK {
+		eb.endpoint.release(endpointProbe)
+		return nil, status.Errorf(codes.Unavailable, "circuit breaker of %s of endpoint %s is open", path, eb.endpoint.endpoint)
+	}
+	return func(err error, latency time.Duration) {
+		eb.endpoint.record(endpointProbe, err, latency)
+		methodBreaker.record(methodProbe, err, latency)
+	}, nil
+}
+
+// isOpen returns true if the circuit of the whole endpoint is open
+func (eb *endpointBreakers) isOpen() bool {
+	return eb != nil && eb.endpoint.isOpen()
+}
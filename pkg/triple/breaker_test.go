@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var changes []string
+	cb := newCircuitBreaker(&config.CircuitBreaker{
+		MinRequests:      4,
+		SlowCallDuration: time.Second,
+		OpenDuration:     time.Second * 5,
+		HalfOpenProbes:   2,
+		OnStateChange: func(endpoint, method string, from, to config.CircuitState) {
+			assert.Equal(t, "127.0.0.1:20000", endpoint)
+			assert.Equal(t, "/svc/Method", method)
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	}, "127.0.0.1:20000", "/svc/Method")
+	now := time.Now()
+	cb.now = func() time.Time { return now }
+	unavailable := status.Err(codes.Unavailable, "unavailable")
+
+	// not opened before min requests, and client errors are not failures
+	cb.record(false, unavailable, 0)
+	cb.record(false, status.Err(codes.NotFound, "not found"), 0)
+	cb.record(false, status.Err(codes.Canceled, "canceled"), 0)
+	cb.record(false, unavailable, 0)
+	ok, _ := cb.allow()
+	assert.True(t, ok)
+	assert.False(t, cb.isOpen())
+
+	// failure ratio reaches 0.5
+	cb.record(false, nil, 0)
+	assert.True(t, cb.isOpen())
+	ok, _ = cb.allow()
+	assert.False(t, ok)
+
+	// half-open after open duration, with limited probes
+	now = now.Add(time.Second * 5)
+	assert.False(t, cb.isOpen())
+	ok, probe := cb.allow()
+	assert.True(t, ok && probe)
+	ok, probe = cb.allow()
+	assert.True(t, ok && probe)
+	ok, _ = cb.allow()
+	assert.False(t, ok)
+	// slow probe opens the circuit again
+	cb.record(true, nil, time.Second*2)
+	assert.True(t, cb.isOpen())
+
+	// all probes succeed
+	now = now.Add(time.Second * 5)
+	ok, probe = cb.allow()
+	assert.True(t, ok && probe)
+	ok, probe = cb.allow()
+	assert.True(t, ok && probe)
+	cb.record(true, nil, 0)
+	cb.record(true, nil, 0)
+	ok, probe = cb.allow()
+	assert.True(t, ok)
+	assert.False(t, probe)
+
+	// failures slide out of window
+	for i := 0; i < 3; i++ {
+		cb.record(false, unavailable, 0)
+	}
+	now = now.Add(defaultBreakerWindow)
+	for i := 0; i < 3; i++ {
+		cb.record(false, nil, 0)
+	}
+	cb.record(false, unavailable, 0)
+	assert.False(t, cb.isOpen())
+
+	assert.Equal(t, []string{
+		"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed",
+	}, changes)
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	service := newFlakyService(100, 0)
+	serviceMap := &sync.Map{}
+	serviceMap.Store(flakyServiceName, service)
+	server, url := startTestServer(t, serviceMap, nil)
+	defer server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	var opened int32
+	client, err := NewTripleClient(url, testStubImpl{}, config.NewTripleOption(
+		config.WithCircuitBreaker(&config.CircuitBreaker{
+			MinRequests: 2,
+			OnStateChange: func(endpoint, method string, from, to config.CircuitState) {
+				if to == config.CircuitOpen {
+					atomic.AddInt32(&opened, 1)
+				}
+			},
+		}),
+	))
+	assert.Nil(t, err)
+	defer client.Close()
+
+	callPath := "/" + flakyServiceName + "/Call"
+	for i := 0; i < 2; i++ {
+		err = client.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	}
+	// both breakers of endpoint and method are open
+	assert.Equal(t, int32(2), atomic.LoadInt32(&opened))
+	assert.False(t, client.IsAvailable())
+
+	// fail fast without calling server
+	err = client.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.True(t, strings.Contains(status.Convert(err).Message(), "circuit breaker"))
+	_, err = client.StreamRequest(ctx, "/"+flakyServiceName+"/CallStream")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&service.calls))
+}
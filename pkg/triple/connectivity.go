@@ -0,0 +1,398 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+import (
+	logger "github.com/dubbogo/gost/dubbogo/logger"
+
+	h2 "github.com/dubbogo/net/http2"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+// ConnectivityState is the state of client connection
+type ConnectivityState int
+
+const (
+	// Idle means client is not connecting, it starts connecting when an invocation comes or Connect is called
+	Idle ConnectivityState = iota
+	// Connecting means client is connecting
+	Connecting
+	// Ready means client is connected, and invocations can be sent
+	Ready
+	// TransientFailure means client failed to connect, and is waiting to reconnect after backoff.
+	// Invocations fail fast with codes.Unavailable, unless they wait for ready.
+	TransientFailure
+	// Shutdown means client is closed
+	Shutdown
+)
+
+func (s ConnectivityState) String() string {
+	switch s {
+	case Idle:
+		return "IDLE"
+	case Connecting:
+		return "CONNECTING"
+	case Ready:
+		return "READY"
+	case TransientFailure:
+		return "TRANSIENT_FAILURE"
+	case Shutdown:
+		return "SHUTDOWN"
+	}
+	return "UNKNOWN"
+}
+
+// connectivityStateManager stores connectivity state, and notifies waiters when it changes
+type connectivityStateManager struct {
+	mu         sync.Mutex
+	state      ConnectivityState
+	notifyChan chan struct{}
+}
+
+func newConnectivityStateManager() *connectivityStateManager {
+	return &connectivityStateManager{
+		state:      Idle,
+		notifyChan: make(chan struct{}),
+	}
+}
+
+// updateState changes state to @state, it returns false if state is not changed. Shutdown state is never changed.
+func (csm *connectivityStateManager) updateState(state ConnectivityState) bool {
+	csm.mu.Lock()
+	defer csm.mu.Unlock()
+	if csm.state == state || csm.state == Shutdown {
+		return false
+	}
+	csm.state = state
+	close(csm.notifyChan)
+	csm.notifyChan = make(chan struct{})
+	return true
+}
+
+// getState returns current state, and chan closed when state changes
+func (csm *connectivityStateManager) getState() (ConnectivityState, <-chan struct{}) {
+	csm.mu.Lock()
+	defer csm.mu.Unlock()
+	return csm.state, csm.notifyChan
+}
+
+// waitForStateChange waits until state is not @sourceState, it returns false if @ctx is done first
+func (csm *connectivityStateManager) waitForStateChange(ctx context.Context, sourceState ConnectivityState) bool {
+	for {
+		state, ch := csm.getState()
+		if state != sourceState {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ch:
+		}
+	}
+}
+
+// clientConn is the http2 connection of client to an address, it reconnects with backoff when connection fails or drops
+type clientConn struct {
+	address   string
+	backoff   config.ConnectBackoff
+	transport *h2.Transport
+	csm       *connectivityStateManager
+	// onStateChange is called without lock when state changes, it may be nil
+	onStateChange func()
+
+	// mu protects fields below, and state changes of csm
+	mu sync.Mutex
+	cc *h2.ClientConn
+	// lastErr is the error of the last connection attempt
+	lastErr error
+	// cancelDial cancels the running connection attempt when conn is closed
+	cancelDial context.CancelFunc
+}
+
+// newClientConn returns idle clientConn to @address, which reconnects with @backoff
+func newClientConn(address string, backoff config.ConnectBackoff, onStateChange func()) *clientConn {
+	return &clientConn{
+		address:       address,
+		backoff:       backoff,
+		transport:     &h2.Transport{},
+		csm:           newConnectivityStateManager(),
+		onStateChange: onStateChange,
+	}
+}
+
+// getState returns current state of connection
+func (c *clientConn) getState() ConnectivityState {
+	state, _ := c.csm.getState()
+	return state
+}
+
+// isAvailable returns false if connection is in transient failure or shut down
+func (c *clientConn) isAvailable() bool {
+	state := c.getState()
+	return state != TransientFailure && state != Shutdown
+}
+
+// connect starts connecting if connection is idle
+func (c *clientConn) connect() {
+	c.mu.Lock()
+	changed := c.connectLocked()
+	c.mu.Unlock()
+	c.notifyStateChange(changed)
+}
+
+// connectLocked starts connecting if connection is idle, it returns if state is changed
+func (c *clientConn) connectLocked() bool {
+	if c.getState() != Idle {
+		return false
+	}
+	return c.startConnectingLocked()
+}
+
+// startConnectingLocked starts a goroutine connecting to address, it returns if state is changed
+func (c *clientConn) startConnectingLocked() bool {
+	if c.cancelDial != nil {
+		c.cancelDial()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancelDial = cancel
+	go c.connectLoop(ctx)
+	return c.csm.updateState(Connecting)
+}
+
+// connectLoop dials until connection is ready or @ctx is canceled, and waits backoff between failed attempts
+func (c *clientConn) connectLoop(ctx context.Context) {
+	for retries := 0; ; retries++ {
+		cc, err := c.dial(ctx)
+
+		c.mu.Lock()
+		if ctx.Err() != nil {
+			c.mu.Unlock()
+			if cc != nil {
+				cc.Close()
+			}
+			return
+		}
+		if err == nil {
+			c.cc = cc
+			c.lastErr = nil
+			changed := c.csm.updateState(Ready)
+			c.mu.Unlock()
+			c.notifyStateChange(changed)
+			logger.Infof("triple client connected to %s", c.address)
+			return
+		}
+		c.lastErr = err
+		changed := c.csm.updateState(TransientFailure)
+		c.mu.Unlock()
+		c.notifyStateChange(changed)
+
+		delay := c.backoffDelay(retries)
+		logger.Warnf("triple client connect to %s error = %v, reconnect after %v", c.address, err, delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		c.mu.Lock()
+		if ctx.Err() != nil {
+			c.mu.Unlock()
+			return
+		}
+		changed = c.csm.updateState(Connecting)
+		c.mu.Unlock()
+		c.notifyStateChange(changed)
+	}
+}
+
+// dial connects to address in MinConnectTimeout, and starts http2 connection on it
+func (c *clientConn) dial(ctx context.Context) (*h2.ClientConn, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, c.backoff.MinConnectTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(dialCtx, "tcp", c.address)
+	if err != nil {
+		return nil, err
+	}
+	wc := &watchedConn{Conn: conn}
+	cc, err := c.transport.NewClientConn(wc)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	wc.setOnClose(func() {
+		c.onConnClosed(cc)
+	})
+	return cc, nil
+}
+
+// backoffDelay returns backoff after failed attempt with @retries former failed attempts
+func (c *clientConn) backoffDelay(retries int) time.Duration {
+	backoff := float64(c.backoff.BaseDelay) * math.Pow(c.backoff.Multiplier, float64(retries))
+	if max := float64(c.backoff.MaxDelay); backoff > max {
+		backoff = max
+	}
+	backoff *= 1 + c.backoff.Jitter*(rand.Float64()*2-1)
+	if backoff < 0 {
+		return 0
+	}
+	return time.Duration(backoff)
+}
+
+// onConnClosed is called when http2 connection @cc is closed, e.g. server closes it or network fails.
+// Client reconnects at once if @cc is the current connection.
+func (c *clientConn) onConnClosed(cc *h2.ClientConn) {
+	c.mu.Lock()
+	if c.cc != cc {
+		c.mu.Unlock()
+		return
+	}
+	changed := c.resetLocked()
+	c.mu.Unlock()
+	c.notifyStateChange(changed)
+	logger.Warnf("triple client connection to %s is closed, reconnecting", c.address)
+}
+
+// resetLocked drops current connection and starts reconnecting, it returns if state is changed
+func (c *clientConn) resetLocked() bool {
+	if c.getState() == Shutdown {
+		return false
+	}
+	c.cc = nil
+	return c.startConnectingLocked()
+}
+
+// getConn returns ready http2 connection. It connects if connection is idle, and waits while connecting.
+// In transient failure, it fails fast with codes.Unavailable, or waits until ready if @waitForReady is true.
+// It returns error when @ctx is done first.
+func (c *clientConn) getConn(ctx context.Context, waitForReady bool) (*h2.ClientConn, error) {
+	for {
+		c.mu.Lock()
+		state, ch := c.csm.getState()
+		changed := false
+		switch state {
+		case Ready:
+			cc := c.cc
+			if cc.CanTakeNewRequest() {
+				c.mu.Unlock()
+				return cc, nil
+			}
+			// server sends GOAWAY, new invocations go to a new connection
+			changed = c.resetLocked()
+		case Idle:
+			changed = c.connectLocked()
+		case TransientFailure:
+			if !waitForReady {
+				err := c.lastErr
+				c.mu.Unlock()
+				return nil, status.Errorf(codes.Unavailable, "triple connection to %s is unavailable, err = %v", c.address, err)
+			}
+		case Shutdown:
+			c.mu.Unlock()
+			return nil, status.Errorf(codes.Unavailable, "triple connection to %s is closed", c.address)
+		}
+		if changed {
+			// state has changed, get it again
+			c.mu.Unlock()
+			c.notifyStateChange(changed)
+			continue
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-ch:
+		}
+	}
+}
+
+// close shuts down connection, and stops reconnecting
+func (c *clientConn) close() {
+	c.mu.Lock()
+	changed := c.csm.updateState(Shutdown)
+	cc := c.cc
+	c.cc = nil
+	if c.cancelDial != nil {
+		c.cancelDial()
+	}
+	c.mu.Unlock()
+	c.notifyStateChange(changed)
+	if cc != nil {
+		cc.Close()
+	}
+}
+
+func (c *clientConn) notifyStateChange(changed bool) {
+	if changed && c.onStateChange != nil {
+		c.onStateChange()
+	}
+}
+
+// watchedConn is net.Conn that calls onClose once when it's closed, http2 connection closes it when it fails
+type watchedConn struct {
+	net.Conn
+
+	mu      sync.Mutex
+	closed  bool
+	onClose func()
+}
+
+// setOnClose sets @onClose, which is called at once if conn is already closed
+func (wc *watchedConn) setOnClose(onClose func()) {
+	wc.mu.Lock()
+	if !wc.closed {
+		wc.onClose = onClose
+		wc.mu.Unlock()
+		return
+	}
+	wc.mu.Unlock()
+	onClose()
+}
+
+func (wc *watchedConn) Close() error {
+	err := wc.Conn.Close()
+	wc.mu.Lock()
+	if wc.closed {
+		wc.mu.Unlock()
+		return err
+	}
+	wc.closed = true
+	onClose := wc.onClose
+	wc.mu.Unlock()
+	if onClose != nil {
+		onClose()
+	}
+	return err
+}
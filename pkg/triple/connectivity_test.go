@@ -0,0 +1,191 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+// testProxy forwards tcp connections to target, it can be stopped to drop all connections and started again
+type testProxy struct {
+	t      *testing.T
+	addr   string
+	target string
+
+	mu      sync.Mutex
+	lst     net.Listener
+	conns   []net.Conn
+	stopped bool
+}
+
+func startTestProxy(t *testing.T, target string) *testProxy {
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	p := &testProxy{t: t, addr: lst.Addr().String(), target: target, lst: lst}
+	go p.serve(lst)
+	return p
+}
+
+func (p *testProxy) serve(lst net.Listener) {
+	for {
+		conn, err := lst.Accept()
+		if err != nil {
+			return
+		}
+		backend, err := net.Dial("tcp", p.target)
+		if err != nil {
+			conn.Close()
+			continue
+		}
+		p.mu.Lock()
+		if p.stopped {
+			// proxy is stopped while connecting to target
+			p.mu.Unlock()
+			conn.Close()
+			backend.Close()
+			continue
+		}
+		p.conns = append(p.conns, conn, backend)
+		p.mu.Unlock()
+		go io.Copy(backend, conn)
+		go io.Copy(conn, backend)
+	}
+}
+
+func (p *testProxy) start() {
+	lst, err := net.Listen("tcp", p.addr)
+	assert.Nil(p.t, err)
+	p.mu.Lock()
+	p.lst = lst
+	p.stopped = false
+	p.mu.Unlock()
+	go p.serve(lst)
+}
+
+func (p *testProxy) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	p.lst.Close()
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = nil
+}
+
+// waitForState waits until state of @client is @state
+func waitForState(t *testing.T, client *TripleClient, state ConnectivityState) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	for s := client.GetState(); s != state; s = client.GetState() {
+		if !client.WaitForStateChange(ctx, s) {
+			t.Fatalf("state is %s, want %s", s, state)
+		}
+	}
+}
+
+func TestClientConnectLazily(t *testing.T) {
+	// nothing listens on the address of test url
+	client, err := NewTripleClient(newTestURL(t), testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+	assert.Equal(t, Idle, client.GetState())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	err = client.Request(ctx, "/"+flakyServiceName+"/Call", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.NotEqual(t, Idle, client.GetState())
+}
+
+func TestClientConnectivity(t *testing.T) {
+	server, addr := startFlakyServer(t, newFlakyService(0, 0))
+	defer server.Stop()
+	proxy := startTestProxy(t, addr)
+	defer proxy.stop()
+	url := urlWithLocation(newTestURL(t), proxy.addr)
+	backoff := config.WithConnectBackoff(&config.ConnectBackoff{
+		BaseDelay: time.Millisecond * 50,
+		MaxDelay:  time.Millisecond * 100,
+	})
+	callPath := "/" + flakyServiceName + "/Call"
+	call := func(ctx context.Context, client *TripleClient) error {
+		return client.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	client, err := NewTripleClient(url, testStubImpl{}, config.NewTripleOption(backoff))
+	assert.Nil(t, err)
+	assert.Equal(t, Idle, client.GetState())
+	assert.Nil(t, call(ctx, client))
+	assert.Equal(t, Ready, client.GetState())
+
+	waitClient, err := NewTripleClient(url, testStubImpl{}, config.NewTripleOption(backoff,
+		config.WithMethodConfig("", &config.MethodConfig{WaitForReady: true})))
+	assert.Nil(t, err)
+	defer waitClient.Close()
+	waitClient.Connect()
+	waitForState(t, waitClient, Ready)
+
+	// connection drops, and client fails to reconnect
+	proxy.stop()
+	waitForState(t, client, TransientFailure)
+	assert.False(t, client.IsAvailable())
+	waitForState(t, waitClient, TransientFailure)
+
+	// invocations fail fast, or wait until deadline if they wait for ready
+	err = call(ctx, client)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	shortCtx, shortCancel := context.WithTimeout(ctx, time.Millisecond*200)
+	err = call(shortCtx, waitClient)
+	shortCancel()
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// invocation waiting for ready succeeds after client reconnects
+	done := make(chan error)
+	go func() {
+		done <- call(ctx, waitClient)
+	}()
+	time.Sleep(time.Millisecond * 100)
+	proxy.start()
+	assert.Nil(t, <-done)
+	waitForState(t, client, Ready)
+	assert.True(t, client.IsAvailable())
+	assert.Nil(t, call(ctx, client))
+
+	client.Close()
+	assert.Equal(t, Shutdown, client.GetState())
+	assert.Equal(t, codes.Unavailable, status.Code(call(ctx, client)))
+}
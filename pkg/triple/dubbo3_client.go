@@ -19,8 +19,11 @@ package triple
 
 import (
 	"context"
+	"net"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
 )
 
 import (
@@ -35,8 +38,10 @@ import (
 import (
 	"github.com/dubbogo/triple/internal/codec"
 	"github.com/dubbogo/triple/internal/tools"
+	"github.com/dubbogo/triple/pkg/codes"
 	"github.com/dubbogo/triple/pkg/common"
 	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
 )
 
 // TripleClient client endpoint for client end
@@ -46,6 +51,9 @@ type TripleClient struct {
 	StubInvoker  reflect.Value
 	url          *dubboCommon.URL
 
+	// hessianStreams are StreamDesc of streaming methods of hessian impl, keyed by method name
+	hessianStreams map[string]grpc.StreamDesc
+
 	//once is used when destroy
 	once sync.Once
 
@@ -54,38 +62,168 @@ type TripleClient struct {
 
 	// serializer is triple serializer to do codec
 	serializer common.Dubbo3Serializer
+
+	// closeChan is closed when client is closed
+	closeChan chan struct{}
+
+	// notServing is set to 1 when health service of server reports not serving
+	notServing int32
+
+	// retryThrottler throttles retries of all invocations of client, nil means no throttling
+	retryThrottler *retryThrottler
+
+	// resolver resolves target of client to addresses, it's nil if client connects to the single location of url
+	resolver Resolver
+	// balancer picks one of conns for each invocation
+	balancer Balancer
+	// connsLock protects conns
+	connsLock sync.RWMutex
+	// conns are clients connecting to each address resolved, sorted by address
+	conns []*TripleClient
+	// csm stores connectivity state of client created with target, which is aggregated from states of conns
+	csm *connectivityStateManager
+
+	// onStateChange is called when connectivity state of client changes, it's set for conns of client created with target
+	onStateChange func()
 }
 
 // NewTripleClient create triple client with given @url,
 // it's return tripleClient , contains invoker, and contain triple conn
 // @url is the invocation url when dubbo client invoct. Now, triple only use Location and Protocol field of url.
 // @impl must have method: GetDubboStub(cc *dubbo3.TripleConn) interface{}, to be capable with grpc
-// @opt is used to init http2 controller, if it's nil, use the default config
+// @opt is used to init http2 controller, if it's nil, use the default config.
+// Service config json in url parameter common.ServiceConfigKey overrides method configs of @opt.
+// The client doesn't dial in constructor, it stays IDLE until the first invocation or Connect is called,
+// so unreachable @url is reported by invocations with codes.Unavailable rather than by NewTripleClient.
+// Use Connect and WaitForStateChange to wait until the client is READY.
 func NewTripleClient(url *dubboCommon.URL, impl interface{}, opt *config.Option) (*TripleClient, error) {
-	opt = tools.AddDefaultOption(opt)
+	opt, err := withURLServiceConfig(url, tools.AddDefaultOption(opt))
+	if err != nil {
+		return nil, err
+	}
+	tripleClient, err := newTripleClient(url, opt, nil)
+	if err != nil {
+		return nil, err
+	}
+	tripleClient.setStubInvoker(impl)
+	return tripleClient, nil
+}
+
+// NewTripleClientWithTarget create triple client connecting to all addresses resolved from @target,
+// e.g. static:///a:20000,b:20000 or file:///etc/triple/endpoints.json, and the connection of each invocation
+// is picked by balancer of @opt. Connections are updated when resolved addresses change.
+// @url is used as NewTripleClient does, except that its Location is replaced by the addresses.
+func NewTripleClientWithTarget(target string, url *dubboCommon.URL, impl interface{}, opt *config.Option) (*TripleClient, error) {
+	opt, err := withURLServiceConfig(url, tools.AddDefaultOption(opt))
+	if err != nil {
+		return nil, err
+	}
+	parsedTarget, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := buildResolver(parsedTarget)
+	if err != nil {
+		return nil, err
+	}
+	balancer, err := buildBalancer(opt.Balancer)
+	if err != nil {
+		return nil, err
+	}
+	serializer, err := common.GetDubbo3Serializer(opt.SerializerType)
+	if err != nil {
+		return nil, err
+	}
+
+	tripleClient := &TripleClient{
+		url:            url,
+		opt:            opt,
+		serializer:     serializer,
+		closeChan:      make(chan struct{}),
+		retryThrottler: newRetryThrottler(opt.RetryThrottling),
+		resolver:       resolver,
+		balancer:       balancer,
+		csm:            newConnectivityStateManager(),
+	}
+	if err := resolver.Start(tripleClient.updateAddresses); err != nil {
+		tripleClient.Close()
+		return nil, err
+	}
+	tripleClient.setStubInvoker(impl)
+	return tripleClient, nil
+}
+
+// withURLServiceConfig returns copy of @opt with service config json in parameter of @url,
+// or @opt itself if there is no such parameter
+func withURLServiceConfig(url *dubboCommon.URL, opt *config.Option) (*config.Option, error) {
+	js := url.GetParam(common.ServiceConfigKey, "")
+	if js == "" {
+		return opt, nil
+	}
+	sc, err := config.ParseServiceConfig(js)
+	if err != nil {
+		logger.Errorf("triple client parse service config of url error = %v", err)
+		return nil, err
+	}
+	newOpt := *opt
+	// method configs of @opt are not modified
+	newOpt.MethodConfigs = make(map[string]*config.MethodConfig, len(opt.MethodConfigs)+len(sc.MethodConfigs))
+	for name, mc := range opt.MethodConfigs {
+		newOpt.MethodConfigs[name] = mc
+	}
+	return config.WithServiceConfig(sc)(&newOpt), nil
+}
 
+// newTripleClient creates client connecting to the single location of @url, with default filled @opt.
+// @onStateChange is called when connectivity state of client changes, it can be nil.
+func newTripleClient(url *dubboCommon.URL, opt *config.Option, onStateChange func()) (*TripleClient, error) {
 	tripleClient := &TripleClient{
-		url: url,
-		opt: opt,
+		url:            url,
+		opt:            opt,
+		closeChan:      make(chan struct{}),
+		retryThrottler: newRetryThrottler(opt.RetryThrottling),
+		onStateChange:  onStateChange,
 	}
 	// start triple client connection,
 	if err := tripleClient.connect(url); err != nil {
 		return nil, err
 	}
+	tripleClient.serializer = tripleClient.h2Controller.serializer
 
-	// put dubbo3 network logic to tripleConn, creat pb stub invoker
-	if opt.SerializerType == common.PBSerializerName {
-		tripleClient.StubInvoker = reflect.ValueOf(getInvoker(impl, newTripleConn(tripleClient)))
+	if opt.HealthCheck {
+		go tripleClient.watchHealth()
 	}
-
 	return tripleClient, nil
 }
 
+// serviceDescriber is implemented by hessian impl that has streaming methods, which are described by StreamDesc
+type serviceDescriber interface {
+	ServiceDesc() *grpc.ServiceDesc
+}
+
+// setStubInvoker puts dubbo3 network logic to tripleConn, and creates pb stub invoker with @impl.
+// For hessian serializer, streaming methods of @impl are loaded if it's serviceDescriber.
+func (t *TripleClient) setStubInvoker(impl interface{}) {
+	switch t.opt.SerializerType {
+	case common.PBSerializerName, common.JSONSerializerName:
+		t.StubInvoker = reflect.ValueOf(getInvoker(impl, newTripleConn(t)))
+	case common.TripleHessianWrapperSerializerName:
+		describer, ok := impl.(serviceDescriber)
+		if !ok || describer.ServiceDesc() == nil {
+			return
+		}
+		t.hessianStreams = make(map[string]grpc.StreamDesc)
+		for _, streamDesc := range describer.ServiceDesc().Streams {
+			t.hessianStreams[streamDesc.StreamName] = streamDesc
+		}
+	}
+}
+
 // Invoke call remote using stub
 func (t *TripleClient) Invoke(methodName string, in []reflect.Value) []reflect.Value {
 	rsp := make([]reflect.Value, 0, 2)
 	switch t.opt.SerializerType {
-	case common.PBSerializerName:
+	case common.PBSerializerName, common.JSONSerializerName:
 		method := t.StubInvoker.MethodByName(methodName)
 		// call function in pb.go
 		return method.Call(in)
@@ -93,6 +231,9 @@ func (t *TripleClient) Invoke(methodName string, in []reflect.Value) []reflect.V
 		out := codec.HessianUnmarshalStruct{}
 		ctx := in[0].Interface().(context.Context)
 		interfaceKey := ctx.Value(dubboConstant.DubboCtxKey(dubboConstant.INTERFACE_KEY)).(string)
+		if streamDesc, ok := t.hessianStreams[methodName]; ok {
+			return t.invokeHessianStream(ctx, "/"+interfaceKey+"/"+methodName, streamDesc, in[1:])
+		}
 		err := t.Request(ctx, "/"+interfaceKey+"/"+methodName, in[1].Interface(), &out)
 		rsp = append(rsp, reflect.ValueOf(out.Val))
 		if err != nil {
@@ -105,6 +246,34 @@ func (t *TripleClient) Invoke(methodName string, in []reflect.Value) []reflect.V
 	return append(rsp, reflect.ValueOf(perrors.Errorf("Invalid triple client serializerType = %s", t.opt.SerializerType)))
 }
 
+// invokeHessianStream starts streaming invocation of hessian method described by @desc, and returns the stream,
+// whose messages are hessian args. For server streaming method, @args must be one value, which is sent as
+// the only request message, e.g. []interface{} of all args, otherwise it returns InvalidArgument error.
+func (t *TripleClient) invokeHessianStream(ctx context.Context, path string, desc grpc.StreamDesc, args []reflect.Value) []reflect.Value {
+	rsp := make([]reflect.Value, 0, 2)
+	var (
+		clientStream grpc.ClientStream
+		err          error
+	)
+	if !desc.ClientStreams && len(args) != 1 {
+		err = status.Errorf(codes.InvalidArgument, "server streaming method %s needs one request, but got %d args",
+			path, len(args))
+	} else {
+		clientStream, err = t.StreamRequest(ctx, path)
+	}
+	if err == nil && !desc.ClientStreams {
+		if err = clientStream.SendMsg(args[0].Interface()); err == nil {
+			err = clientStream.CloseSend()
+		}
+	}
+	if err != nil {
+		rsp = append(rsp, reflect.Value{})
+		return append(rsp, reflect.ValueOf(err))
+	}
+	rsp = append(rsp, reflect.ValueOf(clientStream))
+	return append(rsp, reflect.Value{})
+}
+
 // Connect called when new TripleClient, which start a tcp conn with target addr
 func (t *TripleClient) connect(url *dubboCommon.URL) error {
 	logger.Info("want to connect to url = ", url.Location)
@@ -116,47 +285,357 @@ func (t *TripleClient) connect(url *dubboCommon.URL) error {
 		return err
 	}
 	t.h2Controller.address = url.Location
+	t.h2Controller.conn.onStateChange = t.onStateChange
 	return nil
 }
 
-// Request call h2Controller to send unary rpc req to server
+// Request call h2Controller to send unary rpc req to server, the invocation is retried or hedged by config of method
 // @path is /interfaceKey/functionName e.g. /com.apache.dubbo.sample.basic.IGreeter/BigUnaryTest
 // @arg is request body
 func (t *TripleClient) Request(ctx context.Context, path string, arg, reply interface{}) error {
-	if t.h2Controller == nil {
-		if err := t.connect(t.url); err != nil {
-			logger.Errorf("dubbo client connect to url error = %v", err)
-			return err
-		}
+	mc := t.opt.GetMethodConfig(path)
+	if mc != nil && mc.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mc.Timeout)
+		defer cancel()
 	}
-	if err := t.h2Controller.UnaryInvoke(ctx, path, arg, reply); err != nil {
+
+	// request is serialized once, and sent again when retrying or hedging
+	serializer, err := t.callSerializer(ctx)
+	if err != nil {
 		return err
 	}
-	return nil
+	data, err := serializer.MarshalRequest(arg)
+	if err != nil {
+		logger.Errorf("client request marshal error = %v", err)
+		return err
+	}
+	if mc != nil && mc.HedgingPolicy != nil && mc.HedgingPolicy.MaxAttempts > 1 {
+		return t.hedgeUnary(ctx, path, data, reply, mc.HedgingPolicy)
+	}
+	if r := t.newRetryer(path); r != nil {
+		return t.retryUnary(ctx, path, data, reply, r)
+	}
+	conn, err := t.pick(ctx, path, nil)
+	if err != nil {
+		return err
+	}
+	rspData, _, err := conn.h2Controller.unaryInvoke(ctx, path, data)
+	if err != nil {
+		return err
+	}
+	return conn.h2Controller.unmarshalResponse(rspData, reply)
 }
 
 // StreamRequest call h2Controller to send streaming request to sever, to start link.
 // @path is /interfaceKey/functionName e.g. /com.apache.dubbo.sample.basic.IGreeter/BigStreamTest
 func (t *TripleClient) StreamRequest(ctx context.Context, path string) (grpc.ClientStream, error) {
-	if t.h2Controller == nil {
-		if err := t.connect(t.url); err != nil {
-			logger.Errorf("dubbo client connect to url error = %v", err)
+	serializer, err := t.callSerializer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r := t.newRetryer(path); r != nil {
+		rs, err := newRetryClientStream(ctx, t, path, serializer, r)
+		if err != nil {
 			return nil, err
 		}
+		return rs, nil
+	}
+	conn, err := t.pick(ctx, path, nil)
+	if err != nil {
+		return nil, err
 	}
-	return t.h2Controller.StreamInvoke(ctx, path)
+	return conn.h2Controller.streamInvoke(ctx, path, serializer)
+}
+
+// pick returns connection for invocation with @path, which is the client itself if it connects to a single url.
+// Connections in @tried are avoided if there are others, so that retries and hedges go to different addresses.
+// If all connections are in transient failure, invocation of method enabling WaitForReady waits until
+// any connection changes state or @ctx is done, others fail fast with Unavailable.
+func (t *TripleClient) pick(ctx context.Context, path string, tried []*TripleClient) (*TripleClient, error) {
+	if t.resolver == nil {
+		if t.h2Controller == nil {
+			if err := t.connect(t.url); err != nil {
+				logger.Errorf("dubbo client connect to url error = %v", err)
+				return nil, err
+			}
+		}
+		return t, nil
+	}
+
+	available := t.availableConns()
+	for len(available) == 0 {
+		state, stateChan := t.csm.getState()
+		mc := t.opt.GetMethodConfig(path)
+		if state != TransientFailure || mc == nil || !mc.WaitForReady {
+			return nil, status.Errorf(codes.Unavailable, "no available connection of triple client")
+		}
+		select {
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-stateChan:
+		}
+		available = t.availableConns()
+	}
+	untried := make([]*TripleClient, 0, len(available))
+	for _, c := range available {
+		if !containsConn(tried, c) {
+			untried = append(untried, c)
+		}
+	}
+	if len(untried) > 0 {
+		available = untried
+	}
+	return t.balancer.Pick(path, available), nil
+}
+
+// availableConns returns available connections of client created with target
+func (t *TripleClient) availableConns() []*TripleClient {
+	t.connsLock.RLock()
+	defer t.connsLock.RUnlock()
+	available := make([]*TripleClient, 0, len(t.conns))
+	for _, c := range t.conns {
+		if c.IsAvailable() {
+			available = append(available, c)
+		}
+	}
+	return available
+}
+
+// containsConn returns if @conn is in @conns
+func containsConn(conns []*TripleClient, conn *TripleClient) bool {
+	for _, c := range conns {
+		if c == conn {
+			return true
+		}
+	}
+	return false
+}
+
+// updateAddresses replaces connections with ones to @addrs, connections to removed addresses are closed
+// after their in-flight invocations are done
+func (t *TripleClient) updateAddresses(addrs []string) {
+	sorted := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if addr != "" && !containsString(sorted, addr) {
+			sorted = append(sorted, addr)
+		}
+	}
+	sort.Strings(sorted)
+
+	t.connsLock.Lock()
+	select {
+	case <-t.closeChan:
+		t.connsLock.Unlock()
+		return
+	default:
+	}
+	removed := make(map[string]*TripleClient, len(t.conns))
+	for _, c := range t.conns {
+		removed[c.addr] = c
+	}
+	conns := make([]*TripleClient, 0, len(sorted))
+	for _, addr := range sorted {
+		if c, ok := removed[addr]; ok {
+			conns = append(conns, c)
+			delete(removed, addr)
+			continue
+		}
+		c, err := newTripleClient(urlWithLocation(t.url, addr), t.opt, t.updateState)
+		if err != nil {
+			logger.Errorf("triple client connect to %s error = %v", addr, err)
+			continue
+		}
+		conns = append(conns, c)
+	}
+	t.conns = conns
+	t.connsLock.Unlock()
+
+	logger.Infof("triple client addresses are updated to %v", sorted)
+	t.updateState()
+	for _, c := range removed {
+		go c.closeWhenIdle()
+	}
+}
+
+// containsString returns if @s is in @list
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// urlWithLocation returns copy of @url with location @addr
+func urlWithLocation(url *dubboCommon.URL, addr string) *dubboCommon.URL {
+	u := url.Clone()
+	u.Location = addr
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		u.Ip, u.Port = host, port
+	}
+	return u
+}
+
+// closeWhenIdle closes client after its in-flight invocations are done, at most Timeout of option
+func (t *TripleClient) closeWhenIdle() {
+	if t.h2Controller != nil {
+		t.h2Controller.waitInvocationsDone(time.Second * time.Duration(t.opt.Timeout))
+	}
+	t.Close()
+}
+
+// newRetryer returns retryer of invocation with @path, or nil if the method has no retry policy
+func (t *TripleClient) newRetryer(path string) *retryer {
+	mc := t.opt.GetMethodConfig(path)
+	if mc == nil {
+		return nil
+	}
+	return newRetryer(mc.RetryPolicy, t.retryThrottler)
+}
+
+// LoadReport returns the latest load reported by server in trailers, or nil if server doesn't report it.
+// It returns nil for client created with target, whose connections report load separately.
+func (t *TripleClient) LoadReport() *LoadReport {
+	if t.h2Controller == nil {
+		return nil
+	}
+	return t.h2Controller.getLoadReport()
+}
+
+// Address returns the address that client connects to, it's empty for client created with target
+func (t *TripleClient) Address() string {
+	return t.addr
+}
+
+// Inflight returns the count of in-flight invocations of client
+func (t *TripleClient) Inflight() int64 {
+	if t.resolver == nil {
+		if t.h2Controller == nil {
+			return 0
+		}
+		return t.h2Controller.getInflight()
+	}
+	t.connsLock.RLock()
+	defer t.connsLock.RUnlock()
+	inflight := int64(0)
+	for _, c := range t.conns {
+		inflight += c.Inflight()
+	}
+	return inflight
 }
 
 // Close destroy http controller and return
 func (t *TripleClient) Close() {
 	logger.Debug("Triple Client Is closing")
-	t.h2Controller.Destroy()
+	t.once.Do(func() {
+		t.connsLock.Lock()
+		close(t.closeChan)
+		conns := t.conns
+		t.conns = nil
+		t.connsLock.Unlock()
+
+		if t.resolver != nil {
+			t.resolver.Close()
+			t.csm.updateState(Shutdown)
+		}
+		for _, c := range conns {
+			c.Close()
+		}
+		if t.h2Controller != nil {
+			t.h2Controller.Destroy()
+		}
+	})
 }
 
 // IsAvailable returns if ht
+// if health checking is enabled, it returns false when server reports not serving.
+// Client created with target is available if any of its connections is available.
 func (t *TripleClient) IsAvailable() bool {
+	if t.resolver != nil {
+		t.connsLock.RLock()
+		defer t.connsLock.RUnlock()
+		for _, c := range t.conns {
+			if c.IsAvailable() {
+				return true
+			}
+		}
+		return false
+	}
 	if t.h2Controller == nil {
 		return false
 	}
+	if t.opt.HealthCheck && !t.isServing() {
+		return false
+	}
 	return t.h2Controller.IsAvailable()
 }
+
+// GetState returns connectivity state of client.
+// State of client created with target is READY if any connection is ready, otherwise it's CONNECTING
+// if any connection is connecting, then IDLE, and TRANSIENT_FAILURE if all connections fail or there is none.
+func (t *TripleClient) GetState() ConnectivityState {
+	if t.resolver != nil {
+		state, _ := t.csm.getState()
+		return state
+	}
+	if t.h2Controller == nil {
+		return Idle
+	}
+	return t.h2Controller.conn.getState()
+}
+
+// WaitForStateChange waits until connectivity state of client is not @sourceState,
+// it returns false if @ctx is done first
+func (t *TripleClient) WaitForStateChange(ctx context.Context, sourceState ConnectivityState) bool {
+	if t.resolver != nil {
+		return t.csm.waitForStateChange(ctx, sourceState)
+	}
+	if t.h2Controller == nil {
+		return false
+	}
+	return t.h2Controller.conn.csm.waitForStateChange(ctx, sourceState)
+}
+
+// Connect starts connecting if client is idle, otherwise client connects when the first invocation comes
+func (t *TripleClient) Connect() {
+	if t.resolver != nil {
+		t.connsLock.RLock()
+		conns := t.conns
+		t.connsLock.RUnlock()
+		for _, c := range conns {
+			c.Connect()
+		}
+		return
+	}
+	if t.h2Controller != nil {
+		t.h2Controller.conn.connect()
+	}
+}
+
+// updateState updates connectivity state of client created with target by states of its connections
+func (t *TripleClient) updateState() {
+	t.connsLock.Lock()
+	defer t.connsLock.Unlock()
+	connecting, idle := false, false
+	for _, c := range t.conns {
+		switch c.GetState() {
+		case Ready:
+			t.csm.updateState(Ready)
+			return
+		case Connecting:
+			connecting = true
+		case Idle:
+			idle = true
+		}
+	}
+	switch {
+	case connecting:
+		t.csm.updateState(Connecting)
+	case idle:
+		t.csm.updateState(Idle)
+	default:
+		t.csm.updateState(TransientFailure)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+func TestClientServiceConfig(t *testing.T) {
+	server, addr := startFlakyServer(t, newFlakyService(0, 0))
+	defer server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	url := urlWithLocation(newTestURL(t), addr)
+	url.SetParam(common.ServiceConfigKey, `{"methodConfig": [
+		{"name": [{"service": "`+flakyServiceName+`", "method": "Call"}], "maxRequestMessageBytes": 8},
+		{"name": [{"service": "`+flakyServiceName+`", "method": "CallStream"}], "maxResponseMessageBytes": 1}
+	]}`)
+	client, err := NewTripleClient(url, testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	// request larger than max is not sent
+	callPath := "/" + flakyServiceName + "/Call"
+	err = client.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	assert.Nil(t, err)
+	err = client.Request(ctx, callPath, &healthpb.HealthCheckRequest{Service: strings.Repeat("a", 8)}, &healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// response larger than max fails the stream
+	stream, err := client.StreamRequest(ctx, "/"+flakyServiceName+"/CallStream")
+	assert.Nil(t, err)
+	assert.Nil(t, stream.SendMsg(&healthpb.HealthCheckRequest{}))
+	err = stream.RecvMsg(&healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NotEqual(t, io.EOF, err)
+
+	url.SetParam(common.ServiceConfigKey, `{"methodConfig": [{"timeout": "1m"}]}`)
+	_, err = NewTripleClient(url, testStubImpl{}, nil)
+	assert.NotNil(t, err)
+}
@@ -18,16 +18,20 @@
 package triple
 
 import (
+	"context"
 	"io"
 	"net"
 	"net/http"
+	"runtime/debug"
 	"sync"
+	"time"
 )
 
 import (
 	dubboCommon "github.com/dubbogo/gost/dubbogo"
 	logger "github.com/dubbogo/gost/dubbogo/logger"
 	"golang.org/x/net/http2"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 import (
@@ -43,6 +47,24 @@ type TripleServer struct {
 	url           *dubboCommon.URL
 	h2Controller  *H2Controller
 	closeChain    chan struct{}
+	lstCloseOnce  sync.Once
+	stopOnce      sync.Once
+
+	// handler serves requests of all conns, it's wrapped to serve grpc-web requests if grpc-web is enabled,
+	// and http/json requests if http gateway is served on triple port
+	handler http.Handler
+	// h2Server serves http2 conns, h2ShutdownServer is configured with it only to send GOAWAY to its conns on shutdown
+	h2Server         *http2.Server
+	h2ShutdownServer *http.Server
+	// http1Server serves http/1.1 conns of grpc-web and http/json requests, which are put to http1Lst after sniffing
+	http1Server *http.Server
+	http1Lst    *connListener
+	// gatewayServer serves http gateway on its own listener, which listens on gatewayAddr
+	gatewayServer *http.Server
+	gatewayAddr   net.Addr
+
+	// healthService is registered to rpcServiceMap, it serves grpc.health.v1.Health
+	healthService *healthService
 
 	// config
 	opt *config.Option
@@ -50,36 +72,152 @@ type TripleServer struct {
 
 // NewTripleServer can create Server with url and some user impl providers stored in @serviceMap
 // @serviceMap should be sync.Map: "interfaceKey" -> Dubbo3GrpcService
+// grpc.health.v1.Health service is registered to @serviceMap, if user doesn't register one.
+// grpc.reflection.v1alpha.ServerReflection service is registered too, if it's enabled by @opt
 func NewTripleServer(url *dubboCommon.URL, serviceMap *sync.Map, opt *config.Option) *TripleServer {
 	opt = tools.AddDefaultOption(opt)
+	hs := newHealthService()
+	serviceMap.LoadOrStore(HealthServiceName, hs)
+	if opt.ServerReflection {
+		serviceMap.LoadOrStore(ReflectionServiceName, newReflectionService(serviceMap))
+	}
 	return &TripleServer{
 		addr:          url.Location,
 		rpcServiceMap: serviceMap,
 		url:           url,
-		closeChain:    make(chan struct{}, 1),
+		closeChain:    make(chan struct{}),
 		opt:           opt,
+		healthService: hs,
 	}
 }
 
-// Stop
+// SetServingStatus sets serving status of @service, which is reported by grpc.health.v1.Health service.
+// Empty @service means the overall serving status of server.
+func (t *TripleServer) SetServingStatus(service string, servingStatus healthpb.HealthCheckResponse_ServingStatus) {
+	t.healthService.SetServingStatus(service, servingStatus)
+}
+
+// Stop stops accepting new conn, and cancels all running invocations.
+// It can be called more than once, and after GracefulStop.
 func (t *TripleServer) Stop() {
+	t.stopOnce.Do(func() {
+		t.closeListener()
+		if t.h2Controller != nil {
+			t.h2Controller.Destroy()
+		}
+		for _, srv := range []*http.Server{t.http1Server, t.gatewayServer} {
+			if srv == nil {
+				continue
+			}
+			if err := srv.Close(); err != nil {
+				logger.Warnf("triple server close http server error = %v", err)
+			}
+		}
+		close(t.closeChain)
+	})
+}
+
+// GracefulStop sets serving status of all services to NOT_SERVING, so that clients watching health
+// can remove this server. Then it stops accepting new conn, rejects new invocations with codes.Unavailable,
+// sends GOAWAY to http2 conns, and waits for running invocations to finish at most Option.Timeout seconds
+// before stopping the server.
+func (t *TripleServer) GracefulStop() {
+	t.healthService.Shutdown()
+	t.closeListener()
 	if t.h2Controller != nil {
-		t.h2Controller.Destroy()
+		t.h2Controller.startDraining()
+		// h2ShutdownServer has neither listener nor conn of its own, so it returns once GOAWAY is started
+		if err := t.h2ShutdownServer.Shutdown(context.Background()); err != nil {
+			logger.Warnf("triple server send GOAWAY error = %v", err)
+		}
+		t.h2Controller.waitInvocationsDone(time.Second * time.Duration(t.opt.Timeout))
 	}
-	t.closeChain <- struct{}{}
+	t.Stop()
+}
+
+// Addr returns the address server listens on after it's started, whose port is chosen by system
+// if port of url is 0, and returns location of url before it's started
+func (t *TripleServer) Addr() string {
+	if t.lst == nil {
+		return t.addr
+	}
+	return t.lst.Addr().String()
+}
+
+// closeListener closes listener once, to stop accepting new conn
+func (t *TripleServer) closeListener() {
+	t.lstCloseOnce.Do(func() {
+		if t.lst == nil {
+			return
+		}
+		if err := t.lst.Close(); err != nil {
+			logger.Warnf("triple server close listener error = %v", err)
+		}
+	})
 }
 
 // Start can start a triple server
 func (t *TripleServer) Start() {
 	logger.Info("tripleServer Start at ", t.addr)
+	h2Controller, err := NewH2Controller(true, t.rpcServiceMap, t.url, t.opt)
+	if err != nil {
+		panic(err)
+	}
+	t.h2Controller = h2Controller
+	t.h2Server = &http2.Server{}
+	t.h2ShutdownServer = &http.Server{}
+	if err := http2.ConfigureServer(t.h2ShutdownServer, t.h2Server); err != nil {
+		panic(err)
+	}
 	lst, err := net.Listen("tcp", t.addr)
 	if err != nil {
 		panic(err)
 	}
 	t.lst = lst
+	t.handler = http.HandlerFunc(h2Controller.GetHandler())
+	if t.opt.GRPCWeb {
+		t.handler = newGRPCWebHandler(t.handler, t.opt.GRPCWebAllowedOrigins)
+	} else {
+		t.handler = requireHTTP2(t.handler)
+	}
+	if t.opt.HTTPGateway {
+		if t.opt.HTTPGatewayAddr == "" {
+			t.handler = newHTTPGateway(h2Controller, t.handler)
+		} else {
+			t.startGatewayServer(newHTTPGateway(h2Controller, nil))
+		}
+	}
+	if t.opt.GRPCWeb || t.opt.HTTPGateway && t.opt.HTTPGatewayAddr == "" {
+		t.http1Lst = newConnListener(lst.Addr())
+		t.http1Server = &http.Server{Handler: t.handler}
+		go t.http1Server.Serve(t.http1Lst)
+	}
 	go t.run()
 }
 
+// startGatewayServer starts http server of @gateway on its own listener
+func (t *TripleServer) startGatewayServer(gateway *httpGateway) {
+	lst, err := net.Listen("tcp", t.opt.HTTPGatewayAddr)
+	if err != nil {
+		panic(err)
+	}
+	logger.Info("triple http gateway Start at ", lst.Addr())
+	t.gatewayAddr = lst.Addr()
+	t.gatewayServer = &http.Server{Handler: gateway}
+	go t.gatewayServer.Serve(lst)
+}
+
+// requireHTTP2 returns handler that rejects grpc requests not over http2, and passes others to @next
+func requireHTTP2(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			http.Error(w, "triple: grpc requests are only supported over http2", http.StatusUnsupportedMediaType)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // run can start a loop to accept tcp conn
 func (t *TripleServer) run() {
 	go func() {
@@ -96,7 +234,7 @@ func (t *TripleServer) run() {
 		go func() {
 			defer func() {
 				if e := recover(); e != nil {
-					logger.Error(" handle raw conn panic = ", err)
+					logger.Errorf(" handle raw conn panic = %v\n%s", e, debug.Stack())
 				}
 			}()
 			if err := t.handleRawConn(conn); err != nil && err != io.EOF {
@@ -106,15 +244,22 @@ func (t *TripleServer) run() {
 	}
 }
 
-// handleRawConn create a H2 Controller to deal with new conn
+// handleRawConn serves new conn with H2 Controller of server.
+// If grpc-web or http gateway on triple port is enabled, conn without http2 preface is served as http/1.1 conn.
 func (t *TripleServer) handleRawConn(conn net.Conn) error {
-	srv := &http2.Server{}
-	h2Controller, err := NewH2Controller(true, t.rpcServiceMap, t.url, t.opt)
-	if err != nil {
-		return err
+	if t.http1Server != nil {
+		sniffed, isHTTP2, err := sniffHTTP2(conn)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		conn = sniffed
+		if !isHTTP2 {
+			t.http1Lst.put(conn)
+			return nil
+		}
 	}
-	t.h2Controller = h2Controller
-	opts := &http2.ServeConnOpts{Handler: http.HandlerFunc(h2Controller.GetHandler())}
-	srv.ServeConn(conn, opts)
+	opts := &http2.ServeConnOpts{Handler: t.handler}
+	t.h2Server.ServeConn(conn, opts)
 	return nil
 }
@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/pkg/codes"
+)
+
+func TestGracefulStop(t *testing.T) {
+	server, addr := startFlakyServer(t, newFlakyService(0, time.Millisecond*500))
+	callPath := "/" + flakyServiceName + "/Call"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	conn, err := net.Dial("tcp", addr)
+	assert.Nil(t, err)
+	defer conn.Close()
+	cc, err := (&http2.Transport{AllowHTTP: true}).NewClientConn(conn)
+	assert.Nil(t, err)
+	assert.True(t, cc.CanTakeNewRequest())
+
+	client, err := NewTripleClient(urlWithLocation(newTestURL(t), addr), testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+	called := make(chan error)
+	go func() {
+		called <- client.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	}()
+	time.Sleep(time.Millisecond * 100)
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	// conns receive GOAWAY, and new invocations are rejected
+	assert.Eventually(t, func() bool {
+		return !cc.CanTakeNewRequest()
+	}, time.Second, time.Millisecond*10)
+	rsp := httptest.NewRecorder()
+	server.h2Controller.GetHandler()(rsp, httptest.NewRequest(http.MethodPost, callPath, nil))
+	assert.Equal(t, strconv.Itoa(int(codes.Unavailable)), rsp.Header().Get(codec.TrailerKeyGrpcStatus))
+
+	// running invocation finishes before server stops
+	select {
+	case <-stopped:
+		t.Fatal("server stops before running invocation finishes")
+	default:
+	}
+	assert.Nil(t, <-called)
+	<-stopped
+}
+
+func TestStopAfterGracefulStop(t *testing.T) {
+	server, _ := startTestServer(t, &sync.Map{}, nil)
+	server.GracefulStop()
+	stopped := make(chan struct{})
+	go func() {
+		server.Stop()
+		server.Stop()
+		server.h2Controller.Destroy()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocks after server is stopped")
+	}
+}
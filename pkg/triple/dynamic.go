@@ -0,0 +1,322 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+import (
+	protoV1 "github.com/golang/protobuf/proto"
+
+	perrors "github.com/pkg/errors"
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+// DescriptorSource finds descriptors of methods, so that they can be invoked by TripleClient without generated stubs
+type DescriptorSource interface {
+	// FindMethod finds descriptor of method with invocation @path "/service/method", or full name "service.method"
+	FindMethod(ctx context.Context, path string) (protoreflect.MethodDescriptor, error)
+}
+
+// filesSource is DescriptorSource of a FileDescriptorSet
+type filesSource struct {
+	files *protoregistry.Files
+}
+
+// NewFileDescriptorSetSource returns DescriptorSource that finds methods in @fds, e.g. the output of
+// protoc --descriptor_set_out --include_imports. All dependencies of files must be included in @fds.
+func NewFileDescriptorSetSource(fds *descriptorpb.FileDescriptorSet) (DescriptorSource, error) {
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, perrors.Errorf("invalid file descriptor set, err = %v", err)
+	}
+	return &filesSource{files: files}, nil
+}
+
+func (s *filesSource) FindMethod(_ context.Context, path string) (protoreflect.MethodDescriptor, error) {
+	return findMethod(s.files, path)
+}
+
+// findMethod finds descriptor of method with @path in @files
+func findMethod(files *protoregistry.Files, path string) (protoreflect.MethodDescriptor, error) {
+	name := methodFullName(path)
+	desc, err := files.FindDescriptorByName(name)
+	if err != nil {
+		return nil, perrors.Errorf("method %s is not found, err = %v", name, err)
+	}
+	md, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, perrors.Errorf("%s is not a method", name)
+	}
+	return md, nil
+}
+
+// methodFullName converts invocation @path "/service/method" to full name "service.method",
+// full name is returned as it is
+func methodFullName(path string) protoreflect.FullName {
+	if !strings.HasPrefix(path, "/") {
+		return protoreflect.FullName(path)
+	}
+	return protoreflect.FullName(strings.Replace(path[1:], "/", ".", 1))
+}
+
+// methodPath returns invocation path "/service/method" of @md
+func methodPath(md protoreflect.MethodDescriptor) string {
+	return "/" + string(md.Parent().FullName()) + "/" + string(md.Name())
+}
+
+// reflectionSource is DescriptorSource that gets file descriptors from server reflection service of server,
+// file descriptors are cached after they are got
+type reflectionSource struct {
+	client *TripleClient
+
+	mu sync.Mutex
+	// fileProtos are file descriptors got from server, keyed by file name
+	fileProtos map[string]*descriptorpb.FileDescriptorProto
+	files      *protoregistry.Files
+}
+
+// NewServerReflectionSource returns DescriptorSource that gets file descriptors from grpc server reflection service
+// of the server that @client connects to, server must enable config.WithServerReflection
+func NewServerReflectionSource(client *TripleClient) DescriptorSource {
+	return &reflectionSource{
+		client:     client,
+		fileProtos: make(map[string]*descriptorpb.FileDescriptorProto),
+		files:      &protoregistry.Files{},
+	}
+}
+
+func (s *reflectionSource) FindMethod(ctx context.Context, path string) (protoreflect.MethodDescriptor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if md, err := findMethod(s.files, path); err == nil {
+		return md, nil
+	}
+	serviceName := methodFullName(path).Parent()
+	if err := s.fetch(ctx, string(serviceName)); err != nil {
+		return nil, err
+	}
+	return findMethod(s.files, path)
+}
+
+// fetch gets file descriptor that defines @symbol and all its dependencies from server, it must be called with lock
+func (s *reflectionSource) fetch(ctx context.Context, symbol string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := s.client.StreamRequest(ctx, "/"+ReflectionServiceName+"/ServerReflectionInfo")
+	if err != nil {
+		return err
+	}
+	defer stream.CloseSend()
+
+	req := &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	}
+	// requested are files requested by name, each file is requested once
+	requested := make(map[string]bool)
+	for req != nil {
+		if err := s.requestFiles(stream, req); err != nil {
+			return err
+		}
+		req = nil
+		// server may not send dependencies that are sent on other streams
+		if dep := s.missingDependency(requested); dep != "" {
+			requested[dep] = true
+			req = &rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+			}
+		}
+	}
+
+	fds := &descriptorpb.FileDescriptorSet{File: make([]*descriptorpb.FileDescriptorProto, 0, len(s.fileProtos))}
+	for _, fileProto := range s.fileProtos {
+		fds.File = append(fds.File, fileProto)
+	}
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return perrors.Errorf("invalid file descriptors from server reflection, err = %v", err)
+	}
+	s.files = files
+	return nil
+}
+
+// missingDependency returns name of a dependency that is not got or @requested, or "" if there is none
+func (s *reflectionSource) missingDependency(requested map[string]bool) string {
+	for _, fileProto := range s.fileProtos {
+		for _, dep := range fileProto.Dependency {
+			if _, ok := s.fileProtos[dep]; !ok && !requested[dep] {
+				return dep
+			}
+		}
+	}
+	return ""
+}
+
+// requestFiles sends @req on reflection @stream, and stores file descriptors in response
+func (s *reflectionSource) requestFiles(stream grpc.ClientStream, req *rpb.ServerReflectionRequest) error {
+	if err := stream.SendMsg(req); err != nil {
+		return err
+	}
+	rsp := &rpb.ServerReflectionResponse{}
+	if err := stream.RecvMsg(rsp); err != nil {
+		return err
+	}
+	if errRsp := rsp.GetErrorResponse(); errRsp != nil {
+		return status.Errorf(codes.Code(errRsp.ErrorCode), "server reflection error = %s", errRsp.ErrorMessage)
+	}
+	fdRsp := rsp.GetFileDescriptorResponse()
+	if fdRsp == nil {
+		return perrors.Errorf("invalid server reflection response %v", rsp)
+	}
+	for _, data := range fdRsp.FileDescriptorProto {
+		fileProto := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(data, fileProto); err != nil {
+			return err
+		}
+		s.fileProtos[fileProto.GetName()] = fileProto
+	}
+	return nil
+}
+
+// checkDynamicInvocation checks that client uses protobuf or json serializer, and @md is in shape of @streaming
+func (t *TripleClient) checkDynamicInvocation(md protoreflect.MethodDescriptor, streaming bool) error {
+	if t.opt.SerializerType != common.PBSerializerName && t.opt.SerializerType != common.JSONSerializerName {
+		return perrors.Errorf("dynamic invocation needs serializer %s or %s, but it's %s",
+			common.PBSerializerName, common.JSONSerializerName, t.opt.SerializerType)
+	}
+	if isStreaming := md.IsStreamingClient() || md.IsStreamingServer(); isStreaming != streaming {
+		return perrors.Errorf("method %s is streaming = %v", md.FullName(), isStreaming)
+	}
+	return nil
+}
+
+// checkMessageType checks that @m is message of @desc
+func checkMessageType(m proto.Message, desc protoreflect.MessageDescriptor) error {
+	if name := m.ProtoReflect().Descriptor().FullName(); name != desc.FullName() {
+		return perrors.Errorf("message type is %s, but %s is wanted", name, desc.FullName())
+	}
+	return nil
+}
+
+// InvokeDynamic invokes unary method @md with @req, which can be dynamicpb message or generated message.
+// The response is returned as dynamicpb message. Method configs of the method work as Request.
+func (t *TripleClient) InvokeDynamic(ctx context.Context, md protoreflect.MethodDescriptor, req proto.Message) (proto.Message, error) {
+	if err := t.checkDynamicInvocation(md, false); err != nil {
+		return nil, err
+	}
+	if err := checkMessageType(req, md.Input()); err != nil {
+		return nil, err
+	}
+	reply := dynamicpb.NewMessage(md.Output())
+	if err := t.Request(ctx, methodPath(md), protoV1.MessageV1(req), reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// InvokeDynamicJSON invokes unary method @md with request in protobuf json format, and returns response in json
+func (t *TripleClient) InvokeDynamicJSON(ctx context.Context, md protoreflect.MethodDescriptor, reqJSON []byte) ([]byte, error) {
+	req := dynamicpb.NewMessage(md.Input())
+	if err := protojson.Unmarshal(reqJSON, req); err != nil {
+		return nil, perrors.Errorf("invalid json request of %s, err = %v", md.FullName(), err)
+	}
+	reply, err := t.InvokeDynamic(ctx, md, req)
+	if err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(reply)
+}
+
+// DynamicStream is client stream of method invoked dynamically
+type DynamicStream struct {
+	grpc.ClientStream
+	method protoreflect.MethodDescriptor
+}
+
+// NewDynamicStream starts streaming invocation of method @md, which is client streaming, server streaming,
+// or bidi streaming. Messages of the stream can be dynamicpb messages, generated messages, or json.
+func (t *TripleClient) NewDynamicStream(ctx context.Context, md protoreflect.MethodDescriptor) (*DynamicStream, error) {
+	if err := t.checkDynamicInvocation(md, true); err != nil {
+		return nil, err
+	}
+	stream, err := t.StreamRequest(ctx, methodPath(md))
+	if err != nil {
+		return nil, err
+	}
+	return &DynamicStream{
+		ClientStream: stream,
+		method:       md,
+	}, nil
+}
+
+// Method returns descriptor of the method
+func (ds *DynamicStream) Method() protoreflect.MethodDescriptor {
+	return ds.method
+}
+
+// Send sends request @m to server
+func (ds *DynamicStream) Send(m proto.Message) error {
+	if err := checkMessageType(m, ds.method.Input()); err != nil {
+		return err
+	}
+	return ds.ClientStream.SendMsg(protoV1.MessageV1(m))
+}
+
+// SendJSON sends request in protobuf json format to server
+func (ds *DynamicStream) SendJSON(reqJSON []byte) error {
+	req := dynamicpb.NewMessage(ds.method.Input())
+	if err := protojson.Unmarshal(reqJSON, req); err != nil {
+		return perrors.Errorf("invalid json request of %s, err = %v", ds.method.FullName(), err)
+	}
+	return ds.ClientStream.SendMsg(req)
+}
+
+// Recv receives response as dynamicpb message, it returns io.EOF when server finishes the stream with OK
+func (ds *DynamicStream) Recv() (proto.Message, error) {
+	reply := dynamicpb.NewMessage(ds.method.Output())
+	if err := ds.ClientStream.RecvMsg(reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// RecvJSON receives response in protobuf json format, it returns io.EOF when server finishes the stream with OK
+func (ds *DynamicStream) RecvJSON() ([]byte, error) {
+	reply, err := ds.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(reply)
+}
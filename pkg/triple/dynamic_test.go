@@ -0,0 +1,266 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/config"
+)
+
+const echoServiceName = "triple.test.EchoService"
+
+// echoFileProto defines echoService, whose methods in all shapes use google.protobuf.StringValue
+var echoFileProto = &descriptorpb.FileDescriptorProto{
+	Name:       proto.String("triple/test/echo.proto"),
+	Package:    proto.String("triple.test"),
+	Dependency: []string{"google/protobuf/wrappers.proto"},
+	Syntax:     proto.String("proto3"),
+	Service: []*descriptorpb.ServiceDescriptorProto{{
+		Name: proto.String("EchoService"),
+		Method: []*descriptorpb.MethodDescriptorProto{
+			newEchoMethodProto("Echo", false, false),
+			newEchoMethodProto("Collect", true, false),
+			newEchoMethodProto("Split", false, true),
+			newEchoMethodProto("Chat", true, true),
+		},
+	}},
+}
+
+func newEchoMethodProto(name string, clientStreaming, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+	return &descriptorpb.MethodDescriptorProto{
+		Name:            proto.String(name),
+		InputType:       proto.String(".google.protobuf.StringValue"),
+		OutputType:      proto.String(".google.protobuf.StringValue"),
+		ClientStreaming: proto.Bool(clientStreaming),
+		ServerStreaming: proto.Bool(serverStreaming),
+	}
+}
+
+func init() {
+	// register echo.proto, so that server reflection can find it
+	fd, err := protodesc.NewFile(echoFileProto, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+		panic(err)
+	}
+}
+
+// echoService is a pb service without generated code
+type echoService struct {
+	testService
+}
+
+func (e *echoService) ServiceDesc() *grpc.ServiceDesc {
+	return &echoServiceDesc
+}
+
+var echoServiceDesc = grpc.ServiceDesc{
+	ServiceName: echoServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Echo",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(wrapperspb.StringValue)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return in, nil
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			// Collect concatenates all requests
+			StreamName: "Collect",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				out := new(wrapperspb.StringValue)
+				for {
+					in := new(wrapperspb.StringValue)
+					if err := stream.RecvMsg(in); err == io.EOF {
+						return stream.SendMsg(out)
+					} else if err != nil {
+						return err
+					}
+					out.Value += in.Value
+				}
+			},
+			ClientStreams: true,
+		},
+		{
+			// Split sends each character of request
+			StreamName: "Split",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				in := new(wrapperspb.StringValue)
+				if err := stream.RecvMsg(in); err != nil {
+					return err
+				}
+				for _, c := range in.Value {
+					if err := stream.SendMsg(wrapperspb.String(string(c))); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			ServerStreams: true,
+		},
+		{
+			// Chat echoes each request
+			StreamName: "Chat",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				for {
+					in := new(wrapperspb.StringValue)
+					if err := stream.RecvMsg(in); err == io.EOF {
+						return nil
+					} else if err != nil {
+						return err
+					}
+					if err := stream.SendMsg(in); err != nil {
+						return err
+					}
+				}
+			},
+			ClientStreams: true,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "triple/test/echo.proto",
+}
+
+// stringValue returns value of dynamic google.protobuf.StringValue @m
+func stringValue(m proto.Message) string {
+	return m.ProtoReflect().Get(m.ProtoReflect().Descriptor().Fields().ByName("value")).String()
+}
+
+func TestDynamicInvocation(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(echoServiceName, &echoService{})
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(config.WithServerReflection()))
+	defer server.Stop()
+	client, err := NewTripleClient(url, testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	source, err := NewFileDescriptorSetSource(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto),
+			echoFileProto,
+		},
+	})
+	assert.Nil(t, err)
+	_, err = source.FindMethod(ctx, "/"+echoServiceName+"/Unknown")
+	assert.NotNil(t, err)
+
+	// unary
+	echo, err := source.FindMethod(ctx, "/"+echoServiceName+"/Echo")
+	assert.Nil(t, err)
+	req := dynamicpb.NewMessage(echo.Input())
+	req.Set(echo.Input().Fields().ByName("value"), protoreflect.ValueOfString("hello"))
+	reply, err := client.InvokeDynamic(ctx, echo, req)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", stringValue(reply))
+	reply, err = client.InvokeDynamic(ctx, echo, wrapperspb.String("generated"))
+	assert.Nil(t, err)
+	assert.Equal(t, "generated", stringValue(reply))
+	replyJSON, err := client.InvokeDynamicJSON(ctx, echo, []byte(`"json"`))
+	assert.Nil(t, err)
+	assert.Equal(t, `"json"`, string(replyJSON))
+	_, err = client.InvokeDynamic(ctx, echo, wrapperspb.Int32(1))
+	assert.NotNil(t, err)
+
+	// client streaming
+	collect, err := source.FindMethod(ctx, echoServiceName+".Collect")
+	assert.Nil(t, err)
+	_, err = client.InvokeDynamic(ctx, collect, wrapperspb.String(""))
+	assert.NotNil(t, err)
+	stream, err := client.NewDynamicStream(ctx, collect)
+	assert.Nil(t, err)
+	assert.Nil(t, stream.Send(wrapperspb.String("a")))
+	assert.Nil(t, stream.SendJSON([]byte(`"b"`)))
+	assert.Nil(t, stream.CloseSend())
+	reply, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "ab", stringValue(reply))
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+
+	// server streaming
+	split, err := source.FindMethod(ctx, "/"+echoServiceName+"/Split")
+	assert.Nil(t, err)
+	_, err = client.NewDynamicStream(ctx, echo)
+	assert.NotNil(t, err)
+	stream, err = client.NewDynamicStream(ctx, split)
+	assert.Nil(t, err)
+	assert.Nil(t, stream.SendJSON([]byte(`"abc"`)))
+	assert.Nil(t, stream.CloseSend())
+	for _, c := range []string{`"a"`, `"b"`, `"c"`} {
+		replyJSON, err = stream.RecvJSON()
+		assert.Nil(t, err)
+		assert.Equal(t, c, string(replyJSON))
+	}
+	_, err = stream.RecvJSON()
+	assert.Equal(t, io.EOF, err)
+
+	// bidi streaming, with method found by server reflection
+	reflectionSource := NewServerReflectionSource(client)
+	chat, err := reflectionSource.FindMethod(ctx, "/"+echoServiceName+"/Chat")
+	assert.Nil(t, err)
+	assert.True(t, chat.IsStreamingClient() && chat.IsStreamingServer())
+	stream, err = client.NewDynamicStream(ctx, chat)
+	assert.Nil(t, err)
+	for _, value := range []string{"x", "y"} {
+		assert.Nil(t, stream.Send(wrapperspb.String(value)))
+		reply, err = stream.Recv()
+		assert.Nil(t, err)
+		assert.Equal(t, value, stringValue(reply))
+	}
+	assert.Nil(t, stream.CloseSend())
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+
+	echo, err = reflectionSource.FindMethod(ctx, echoServiceName+".Echo")
+	assert.Nil(t, err)
+	reply, err = client.InvokeDynamic(ctx, echo, wrapperspb.String("reflection"))
+	assert.Nil(t, err)
+	assert.Equal(t, "reflection", stringValue(reply))
+	_, err = reflectionSource.FindMethod(ctx, "/unknown.Service/Method")
+	assert.NotNil(t, err)
+}
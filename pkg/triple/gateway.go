@@ -0,0 +1,716 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+import (
+	logger "github.com/dubbogo/gost/dubbogo/logger"
+	perrors "github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/internal/stream"
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+const (
+	// gatewayHeaderPrefix prefixes keys of header metadata set by handler in http response headers
+	gatewayHeaderPrefix = "grpc-metadata-"
+	// gatewayTrailerPrefix prefixes keys of trailer metadata set by handler in http response headers
+	gatewayTrailerPrefix = "grpc-trailer-"
+)
+
+// errUnknownField is returned when field path of path variable or query parameter is not found in message
+var errUnknownField = perrors.New("unknown field")
+
+// httpGateway transcodes http/json requests to unary invocations of pb services registered to server.
+// Methods are routed by google.api.http annotations of their descriptors, and all of them can be called by
+// POST /{service}/{method} with request message in body.
+type httpGateway struct {
+	hc *H2Controller
+	// next serves grpc requests if gateway is served on triple port, it's nil if gateway has its own listener
+	next http.Handler
+
+	// routes are loaded by the first request, and loaded again when a request matches none of them,
+	// as services may be registered after server is created. routesVersion is increased once routes are loaded,
+	// so that requests missing the same version load routes only once.
+	reloadLock    sync.Mutex
+	routesLock    sync.RWMutex
+	routesVersion int
+	routes        []*gatewayRoute
+	fallbacks     map[string]*gatewayRoute
+}
+
+// gatewayRoute maps http requests of httpMethod and path template to unary method of pb service
+type gatewayRoute struct {
+	service    common.Dubbo3GrpcService
+	methodDesc grpc.MethodDesc
+	// path is the path of grpc invocation, like /{service}/{method}
+	path string
+
+	httpMethod string
+	template   *pathTemplate
+	// body is "*" if request body is mapped to request message, or name of field that body is mapped to,
+	// and it's empty if there is no request body
+	body string
+	// responseBody is name of the field of response message that is sent as body, or empty to send the whole message
+	responseBody string
+}
+
+// newHTTPGateway returns http handler of gateway, which invokes services of @hc, requests of grpc and grpc-web
+// are passed to @next if it's not nil
+func newHTTPGateway(hc *H2Controller, next http.Handler) *httpGateway {
+	return &httpGateway{hc: hc, next: next}
+}
+
+func (g *httpGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if g.next != nil && (strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") ||
+		r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "") {
+		g.next.ServeHTTP(w, r)
+		return
+	}
+	route, vars, version := g.match(r)
+	if route == nil {
+		g.reloadRoutes(version)
+		route, vars, _ = g.match(r)
+	}
+	if route == nil {
+		writeGatewayError(w, status.Errorf(codes.NotFound, "no method is mapped to %s %s", r.Method, r.URL.Path))
+		return
+	}
+	g.invoke(w, r, route, vars)
+}
+
+// reloadRoutes loads routes of unary methods of all pb services in rpcServiceMap, unless routes of newer version
+// than @version are loaded by another request
+func (g *httpGateway) reloadRoutes(version int) {
+	g.reloadLock.Lock()
+	defer g.reloadLock.Unlock()
+	g.routesLock.RLock()
+	loaded := g.routesVersion != version
+	g.routesLock.RUnlock()
+	if loaded {
+		return
+	}
+
+	var services []common.Dubbo3GrpcService
+	g.hc.rpcServiceMap.Range(func(_, value interface{}) bool {
+		service, ok := value.(common.Dubbo3GrpcService)
+		if !ok || service.ServiceDesc() == nil {
+			return true
+		}
+		if _, builtin := service.(builtinService); !builtin {
+			services = append(services, service)
+		}
+		return true
+	})
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].ServiceDesc().ServiceName < services[j].ServiceDesc().ServiceName
+	})
+
+	var routes []*gatewayRoute
+	fallbacks := make(map[string]*gatewayRoute)
+	for _, service := range services {
+		desc := service.ServiceDesc()
+		sd := findServiceDescriptor(desc)
+		for _, methodDesc := range desc.Methods {
+			path := "/" + desc.ServiceName + "/" + methodDesc.MethodName
+			fallbacks[path] = &gatewayRoute{
+				service:    service,
+				methodDesc: methodDesc,
+				path:       path,
+				httpMethod: http.MethodPost,
+				body:       "*",
+			}
+			if sd == nil {
+				continue
+			}
+			md := sd.Methods().ByName(protoreflect.Name(methodDesc.MethodName))
+			if md == nil {
+				continue
+			}
+			rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+			for _, r := range append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...) {
+				route, err := newGatewayRoute(r)
+				if err != nil {
+					logger.Warnf("triple http gateway ignores http rule of %s, err = %v", path, err)
+					continue
+				}
+				route.service, route.methodDesc, route.path = service, methodDesc, path
+				routes = append(routes, route)
+			}
+		}
+	}
+
+	g.routesLock.Lock()
+	defer g.routesLock.Unlock()
+	g.routes, g.fallbacks = routes, fallbacks
+	g.routesVersion++
+}
+
+// findServiceDescriptor finds descriptor of service @desc in global protobuf registry, it returns nil if not found
+func findServiceDescriptor(desc *grpc.ServiceDesc) protoreflect.ServiceDescriptor {
+	name := protoreflect.FullName(desc.ServiceName)
+	if d, err := protoregistry.GlobalFiles.FindDescriptorByName(name); err == nil {
+		sd, _ := d.(protoreflect.ServiceDescriptor)
+		return sd
+	}
+	if fileName, ok := desc.Metadata.(string); ok {
+		if fd, err := protoregistry.GlobalFiles.FindFileByPath(fileName); err == nil {
+			return fd.Services().ByName(name.Name())
+		}
+	}
+	return nil
+}
+
+// newGatewayRoute returns route of http @rule, without method to invoke
+func newGatewayRoute(rule *annotations.HttpRule) (*gatewayRoute, error) {
+	var httpMethod, tpl string
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		httpMethod, tpl = http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		httpMethod, tpl = http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		httpMethod, tpl = http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		httpMethod, tpl = http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		httpMethod, tpl = http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		httpMethod, tpl = pattern.Custom.GetKind(), pattern.Custom.GetPath()
+	default:
+		return nil, perrors.New("http rule has no pattern")
+	}
+	template, err := parsePathTemplate(tpl)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayRoute{
+		httpMethod:   httpMethod,
+		template:     template,
+		body:         rule.Body,
+		responseBody: rule.ResponseBody,
+	}, nil
+}
+
+// match returns the first route that matches @r and its path variables, or POST /{service}/{method} route of @r,
+// with version of routes
+func (g *httpGateway) match(r *http.Request) (route *gatewayRoute, vars map[string]string, version int) {
+	g.routesLock.RLock()
+	defer g.routesLock.RUnlock()
+	path := r.URL.EscapedPath()
+	for _, route := range g.routes {
+		if route.httpMethod != "*" && route.httpMethod != r.Method {
+			continue
+		}
+		if vars, ok := route.template.match(path); ok {
+			return route, vars, g.routesVersion
+		}
+	}
+	if route, ok := g.fallbacks[r.URL.Path]; ok && r.Method == http.MethodPost {
+		return route, nil, g.routesVersion
+	}
+	return nil, nil, g.routesVersion
+}
+
+// invoke calls unary handler of @route with request message bound from @r and path variables @vars,
+// and writes response message in json
+func (g *httpGateway) invoke(w http.ResponseWriter, r *http.Request, route *gatewayRoute, vars map[string]string) {
+	if !g.hc.acceptInvocation() {
+		writeGatewayError(w, status.Errorf(codes.Unavailable, "triple: server is stopping"))
+		return
+	}
+	defer g.hc.doneInvocation()
+	if g.hc.loadReporter != nil {
+		g.hc.loadReporter.incInflight()
+		defer g.hc.loadReporter.decInflight()
+	}
+	// request body is limited as request message of triple invocation
+	maxRequestBytes, _ := g.hc.maxMessageBytes(route.path)
+	if maxRequestBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(maxRequestBytes))
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil && maxRequestBytes > 0 && len(body) >= maxRequestBytes {
+		writeGatewayError(w, status.Errorf(codes.ResourceExhausted,
+			"triple: received request body larger than max (%d)", maxRequestBytes))
+		return
+	}
+	if err != nil {
+		writeGatewayError(w, status.Errorf(codes.InvalidArgument, "read request body error: %v", err))
+		return
+	}
+	stream := &gatewayTransportStream{method: route.path}
+	ctx := metadata.NewIncomingContext(r.Context(), headerToMD(r.Header))
+	ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+	dec := func(v interface{}) error {
+		m, ok := codec.AsProtoMessage(v)
+		if !ok {
+			return status.Errorf(codes.Internal, "request of %s is not a pb message", route.path)
+		}
+		return route.bindRequest(m, body, vars, r.URL.Query())
+	}
+
+	reply, err := g.callHandler(ctx, route, dec)
+	var data []byte
+	if err == nil {
+		data, err = route.marshalResponse(reply)
+	}
+	mdToHeader(prefixMD(stream.header, gatewayHeaderPrefix), w.Header(), "")
+	mdToHeader(prefixMD(stream.trailer, gatewayTrailerPrefix), w.Header(), "")
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		logger.Errorf("triple http gateway write response of %s error = %v", route.path, err)
+	}
+}
+
+// callHandler calls unary handler of @route, panic of handler is recovered to codes.Internal error
+func (g *httpGateway) callHandler(ctx context.Context, route *gatewayRoute, dec func(interface{}) error) (reply interface{}, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			stack := debug.Stack()
+			logger.Errorf("triple http gateway handle %s panic = %v\n%s", route.path, e, stack)
+			stream.CallPanicHandler(g.hc.option.PanicHandler, ctx, route.path, e, stack)
+			err = status.Errorf(codes.Internal, "triple server handle %s panic: %v", route.path, e)
+		}
+	}()
+	reply, err = route.methodDesc.Handler(route.service, ctx, dec, nil)
+	if err != nil {
+		if _, ok := status.FromError(err); !ok {
+			err = status.Errorf(codes.Unknown, err.Error())
+		}
+	}
+	return reply, err
+}
+
+// bindRequest sets request message @m from json @body, path variables @vars and @query parameters.
+// Query parameters are bound to fields not bound by body, and those of unknown fields are ignored.
+func (route *gatewayRoute) bindRequest(m proto.Message, body []byte, vars map[string]string, query url.Values) error {
+	unmarshalOpt := protojson.UnmarshalOptions{DiscardUnknown: true}
+	msg := m.ProtoReflect()
+	if len(bytes.TrimSpace(body)) > 0 {
+		switch route.body {
+		case "":
+		case "*":
+			if err := unmarshalOpt.Unmarshal(body, m); err != nil {
+				return status.Errorf(codes.InvalidArgument, "unmarshal request body error: %v", err)
+			}
+		default:
+			fd := findField(msg.Descriptor(), route.body)
+			if fd == nil {
+				return status.Errorf(codes.Internal, "body field %s is not found in %s", route.body, msg.Descriptor().FullName())
+			}
+			// body is unmarshalled as the value of field in request message
+			wrapped := append([]byte(fmt.Sprintf("{%q:", fd.JSONName())), body...)
+			if err := unmarshalOpt.Unmarshal(append(wrapped, '}'), m); err != nil {
+				return status.Errorf(codes.InvalidArgument, "unmarshal request body error: %v", err)
+			}
+		}
+	}
+	for fieldPath, value := range vars {
+		if err := setFieldPath(msg, fieldPath, []string{value}); err != nil {
+			return status.Errorf(codes.InvalidArgument, "bind path variable %s error: %v", fieldPath, err)
+		}
+	}
+	if route.body == "*" {
+		return nil
+	}
+	for fieldPath, values := range query {
+		if _, ok := vars[fieldPath]; ok || fieldPath == route.body {
+			continue
+		}
+		if err := setFieldPath(msg, fieldPath, values); err != nil && perrors.Cause(err) != errUnknownField {
+			return status.Errorf(codes.InvalidArgument, "bind query parameter %s error: %v", fieldPath, err)
+		}
+	}
+	return nil
+}
+
+// marshalResponse marshals @reply to json, or only its field of responseBody if it's set
+func (route *gatewayRoute) marshalResponse(reply interface{}) ([]byte, error) {
+	m, ok := codec.AsProtoMessage(reply)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "response of %s is not a pb message", route.path)
+	}
+	if route.responseBody == "" {
+		return protojson.Marshal(m)
+	}
+	msg := m.ProtoReflect()
+	fd := findField(msg.Descriptor(), route.responseBody)
+	if fd == nil {
+		return nil, status.Errorf(codes.Internal, "response body field %s is not found in %s",
+			route.responseBody, msg.Descriptor().FullName())
+	}
+	// message with only the field is marshalled, and the value of field is picked from it
+	fieldOnly := msg.New()
+	if msg.Has(fd) {
+		fieldOnly.Set(fd, msg.Get(fd))
+	}
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(fieldOnly.Interface())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshal response error: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, status.Errorf(codes.Internal, "marshal response error: %v", err)
+	}
+	return fields[fd.JSONName()], nil
+}
+
+// findField finds field of @desc by its name or json name
+func findField(desc protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	if fd := desc.Fields().ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	return desc.Fields().ByJSONName(name)
+}
+
+// setFieldPath sets field of @msg at dot separated @fieldPath to @values, which are appended if field is repeated,
+// or the last one is set otherwise. Nested messages in the path are created if they are not set.
+func setFieldPath(msg protoreflect.Message, fieldPath string, values []string) error {
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		fd := findField(msg.Descriptor(), name)
+		if fd == nil {
+			return perrors.WithMessagef(errUnknownField, "%s of %s", name, msg.Descriptor().FullName())
+		}
+		if i < len(names)-1 {
+			if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+				return perrors.Errorf("field %s is not a message", name)
+			}
+			msg = msg.Mutable(fd).Message()
+			continue
+		}
+		if fd.IsMap() {
+			return perrors.Errorf("map field %s is not supported", name)
+		}
+		if fd.IsList() {
+			list := msg.Mutable(fd).List()
+			for _, value := range values {
+				v, err := parseFieldValue(fd, value, list.NewElement())
+				if err != nil {
+					return err
+				}
+				list.Append(v)
+			}
+			return nil
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		v, err := parseFieldValue(fd, values[len(values)-1], msg.NewField(fd))
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, v)
+	}
+	return nil
+}
+
+// parseFieldValue parses value of field @fd from string @s, message field is parsed to @empty,
+// which is a new message of the field, and only well-known types that can be json string are supported
+func parseFieldValue(fd protoreflect.FieldDescriptor, s string, empty protoreflect.Value) (protoreflect.Value, error) {
+	var (
+		v   protoreflect.Value
+		err error
+	)
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v = protoreflect.ValueOfBool(b)
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		v = protoreflect.ValueOfEnum(protoreflect.EnumNumber(n))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		v = protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 64)
+		v = protoreflect.ValueOfInt64(n)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, 32)
+		v = protoreflect.ValueOfUint32(uint32(n))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, 64)
+		v = protoreflect.ValueOfUint64(n)
+	case protoreflect.FloatKind:
+		var f float64
+		f, err = strconv.ParseFloat(s, 32)
+		v = protoreflect.ValueOfFloat32(float32(f))
+	case protoreflect.DoubleKind:
+		var f float64
+		f, err = strconv.ParseFloat(s, 64)
+		v = protoreflect.ValueOfFloat64(f)
+	case protoreflect.StringKind:
+		v = protoreflect.ValueOfString(s)
+	case protoreflect.BytesKind:
+		var b []byte
+		if b, err = decodeBinHeader(s); err != nil {
+			b, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		}
+		v = protoreflect.ValueOfBytes(b)
+	case protoreflect.MessageKind:
+		// well-known types like Timestamp are json strings, and wrappers of number or bool are not
+		m := empty.Message().Interface()
+		if err = protojson.Unmarshal([]byte(strconv.Quote(s)), m); err != nil {
+			err = protojson.Unmarshal([]byte(s), m)
+		}
+		v = empty
+	default:
+		return v, perrors.Errorf("field %s of kind %s is not supported", fd.Name(), fd.Kind())
+	}
+	if err != nil {
+		return v, perrors.Errorf("invalid value %q of field %s: %v", s, fd.Name(), err)
+	}
+	return v, nil
+}
+
+// prefixMD returns copy of @md whose keys are prefixed by @prefix
+func prefixMD(md metadata.MD, prefix string) metadata.MD {
+	prefixed := make(metadata.MD, len(md))
+	for k, v := range md {
+		prefixed[prefix+k] = v
+	}
+	return prefixed
+}
+
+// writeGatewayError writes status of @err as json of google.rpc.Status, with http status mapped from its code
+func writeGatewayError(w http.ResponseWriter, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.New(codes.Unknown, err.Error())
+	}
+	data, marshalErr := protojson.Marshal(st.Proto())
+	if marshalErr != nil {
+		// details of status may not be resolved, then they are dropped
+		data, _ = protojson.Marshal(status.New(st.Code(), st.Message()).Proto())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(codeToHTTPStatus(st.Code()))
+	if _, err := w.Write(data); err != nil {
+		logger.Errorf("triple http gateway write error response error = %v", err)
+	}
+}
+
+// codeToHTTPStatus maps grpc code to http status, as google.rpc.Code defines
+func codeToHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		// 499 Client Closed Request
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// gatewayTransportStream is the grpc.ServerTransportStream of invocation from http gateway,
+// it collects header and trailer set by handler, which are sent in http response headers
+type gatewayTransportStream struct {
+	method string
+
+	mu      sync.Mutex
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *gatewayTransportStream) Method() string {
+	return s.method
+}
+
+func (s *gatewayTransportStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *gatewayTransportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *gatewayTransportStream) SetTrailer(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+// pathTemplate is the path template of google.api.http rule, like /v1/{name=shelves/*}/books/{id}:verb
+type pathTemplate struct {
+	// segments are literal, "*" that matches one segment, or "**" that matches the rest segments
+	segments []string
+	vars     []pathVariable
+	verb     string
+}
+
+// pathVariable binds segments in [start, end) to field of fieldPath
+type pathVariable struct {
+	fieldPath  string
+	start, end int
+}
+
+// parsePathTemplate parses path template @tpl of http rule
+func parsePathTemplate(tpl string) (*pathTemplate, error) {
+	if !strings.HasPrefix(tpl, "/") {
+		return nil, perrors.Errorf("path template %q doesn't start with /", tpl)
+	}
+	p := &pathTemplate{}
+	s := tpl[1:]
+	// verb follows the last segment
+	if i := strings.LastIndex(s, ":"); i >= 0 && i > strings.LastIndex(s, "/") && i > strings.LastIndex(s, "}") {
+		p.verb, s = s[i+1:], s[:i]
+	}
+	for len(s) > 0 {
+		if s[0] == '{' {
+			end := strings.IndexByte(s, '}')
+			if end < 0 {
+				return nil, perrors.Errorf("path template %q has unclosed variable", tpl)
+			}
+			fieldPath, sub := s[1:end], "*"
+			if eq := strings.IndexByte(fieldPath, '='); eq >= 0 {
+				fieldPath, sub = fieldPath[:eq], fieldPath[eq+1:]
+			}
+			v := pathVariable{fieldPath: fieldPath, start: len(p.segments)}
+			p.segments = append(p.segments, strings.Split(sub, "/")...)
+			v.end = len(p.segments)
+			p.vars = append(p.vars, v)
+			s = s[end+1:]
+		} else {
+			end := strings.IndexByte(s, '/')
+			if end < 0 {
+				end = len(s)
+			}
+			p.segments = append(p.segments, s[:end])
+			s = s[end:]
+		}
+		if len(s) > 0 {
+			if s[0] != '/' || len(s) == 1 {
+				return nil, perrors.Errorf("path template %q has invalid segment", tpl)
+			}
+			s = s[1:]
+		}
+	}
+	for i, seg := range p.segments {
+		if seg == "" || strings.ContainsAny(seg, "{}=") || seg == "**" && i != len(p.segments)-1 {
+			return nil, perrors.Errorf("path template %q has invalid segment %q", tpl, seg)
+		}
+	}
+	return p, nil
+}
+
+// match returns values of path variables if escaped @path matches the template
+func (p *pathTemplate) match(path string) (map[string]string, bool) {
+	if p.verb != "" {
+		if !strings.HasSuffix(path, ":"+p.verb) {
+			return nil, false
+		}
+		path = strings.TrimSuffix(path, ":"+p.verb)
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	deep := len(p.segments) > 0 && p.segments[len(p.segments)-1] == "**"
+	if deep && len(parts) < len(p.segments)-1 || !deep && len(parts) != len(p.segments) {
+		return nil, false
+	}
+	for i := range parts {
+		unescaped, err := url.PathUnescape(parts[i])
+		if err != nil {
+			return nil, false
+		}
+		parts[i] = unescaped
+	}
+	for i, seg := range p.segments {
+		if seg == "**" {
+			break
+		}
+		if seg == "*" && parts[i] == "" || seg != "*" && seg != parts[i] {
+			return nil, false
+		}
+	}
+	vars := make(map[string]string, len(p.vars))
+	for _, v := range p.vars {
+		end := v.end
+		if deep && end == len(p.segments) {
+			end = len(parts)
+		}
+		vars[v.fieldPath] = strings.Join(parts[v.start:end], "/")
+	}
+	return vars, true
+}
@@ -0,0 +1,308 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+const bookServiceName = "triple.test.BookService"
+
+// bookRequestDesc is descriptor of triple.test.BookRequest, which is both request and response of bookService
+var bookRequestDesc protoreflect.MessageDescriptor
+
+func newBookMethodProto(name string, rule *annotations.HttpRule) *descriptorpb.MethodDescriptorProto {
+	opts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(opts, annotations.E_Http, rule)
+	return &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String(name),
+		InputType:  proto.String(".triple.test.BookRequest"),
+		OutputType: proto.String(".triple.test.BookRequest"),
+		Options:    opts,
+	}
+}
+
+func newFieldProto(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type,
+	label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Type:     typ.Enum(),
+		Label:    label.Enum(),
+	}
+}
+
+func init() {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	book := newFieldProto("book", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional)
+	book.TypeName = proto.String(".triple.test.Book")
+	// book.proto defines bookService, whose methods are mapped to http by google.api.http annotations
+	bookFileProto := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("triple/test/book.proto"),
+		Package:    proto.String("triple.test"),
+		Dependency: []string{"google/api/annotations.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Book"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					newFieldProto("title", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional),
+				},
+			},
+			{
+				Name: proto.String("BookRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					newFieldProto("shelf", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional),
+					newFieldProto("id", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional),
+					book,
+					newFieldProto("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING,
+						descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+					newFieldProto("verbose", 5, descriptorpb.FieldDescriptorProto_TYPE_BOOL, optional),
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("BookService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				newBookMethodProto("GetBook", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/v1/shelves/{shelf}/books/{id}"},
+					AdditionalBindings: []*annotations.HttpRule{
+						{Pattern: &annotations.HttpRule_Get{Get: "/v1/books/{id}:fetch"}},
+					},
+				}),
+				newBookMethodProto("CreateBook", &annotations.HttpRule{
+					Pattern:      &annotations.HttpRule_Post{Post: "/v1/shelves/{shelf}/books"},
+					Body:         "book",
+					ResponseBody: "book",
+				}),
+				newBookMethodProto("MoveBook", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Patch{Patch: "/v1/{book.title=titles/**}"},
+					Body:    "*",
+				}),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(bookFileProto, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+		panic(err)
+	}
+	bookRequestDesc = fd.Messages().ByName("BookRequest")
+}
+
+// bookService is a pb service without generated code, its methods return request, or NotFound if shelf is "missing"
+type bookService struct {
+	testService
+}
+
+func (b *bookService) ServiceDesc() *grpc.ServiceDesc {
+	return &bookServiceDesc
+}
+
+func bookHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := dynamicpb.NewMessage(bookRequestDesc)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	shelf := in.Get(bookRequestDesc.Fields().ByName("shelf")).String()
+	if shelf == "missing" {
+		return nil, status.Errorf(codes.NotFound, "shelf not found")
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs("x-shelf", shelf)); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+var bookServiceDesc = grpc.ServiceDesc{
+	ServiceName: bookServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "GetBook", Handler: bookHandler},
+		{MethodName: "CreateBook", Handler: bookHandler},
+		{MethodName: "MoveBook", Handler: bookHandler},
+	},
+	Metadata: "triple/test/book.proto",
+}
+
+func TestPathTemplate(t *testing.T) {
+	cases := []struct {
+		template string
+		path     string
+		vars     map[string]string
+		ok       bool
+	}{
+		{"/v1/shelves/{shelf}/books/{id}", "/v1/shelves/s1/books/7", map[string]string{"shelf": "s1", "id": "7"}, true},
+		{"/v1/shelves/{shelf}/books/{id}", "/v1/shelves/s1/books", nil, false},
+		{"/v1/shelves/{shelf}/books/{id}", "/v1/shelves//books/7", nil, false},
+		{"/v1/{name=shelves/*}/books", "/v1/shelves/s%2F1/books", map[string]string{"name": "shelves/s/1"}, true},
+		{"/v1/{name=shelves/*}/books", "/v1/racks/s1/books", nil, false},
+		{"/v1/{path=**}", "/v1/a/b/c", map[string]string{"path": "a/b/c"}, true},
+		{"/v1/books/{id}:fetch", "/v1/books/7:fetch", map[string]string{"id": "7"}, true},
+		{"/v1/books/{id}:fetch", "/v1/books/7", nil, false},
+		{"/v1/*/books", "/v1/any/books", map[string]string{}, true},
+	}
+	for _, c := range cases {
+		template, err := parsePathTemplate(c.template)
+		assert.Nil(t, err)
+		vars, ok := template.match(c.path)
+		assert.Equal(t, c.ok, ok, "%s matches %s", c.template, c.path)
+		if c.ok {
+			assert.Equal(t, c.vars, vars)
+		}
+	}
+
+	for _, invalid := range []string{"v1/books", "/v1/{id", "/v1/**/books", "/v1//books", "/v1/books/"} {
+		_, err := parsePathTemplate(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestHTTPGateway(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(bookServiceName, &bookService{})
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(config.WithHTTPGateway(""),
+		config.WithMethodConfig("/"+echoServiceName, &config.MethodConfig{MaxRequestMessageBytes: 16})))
+	defer server.Stop()
+	httpClient := &http.Client{Timeout: time.Second * 3}
+	do := func(baseURL, method, path, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, baseURL+path, strings.NewReader(body))
+		assert.Nil(t, err)
+		rsp, err := httpClient.Do(req)
+		assert.Nil(t, err)
+		data, err := ioutil.ReadAll(rsp.Body)
+		assert.Nil(t, err)
+		rsp.Body.Close()
+		return rsp, string(data)
+	}
+	baseURL := "http://" + url.Location
+
+	// path variables and query parameters, unknown query parameter is ignored
+	rsp, body := do(baseURL, http.MethodGet, "/v1/shelves/s1/books/7?tags=a&tags=b&verbose=true&unknown=1", "")
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, "application/json", rsp.Header.Get("Content-Type"))
+	assert.Equal(t, "s1", rsp.Header.Get("Grpc-Metadata-X-Shelf"))
+	assert.JSONEq(t, `{"shelf":"s1","id":"7","tags":["a","b"],"verbose":true}`, body)
+
+	// additional binding with verb
+	_, body = do(baseURL, http.MethodGet, "/v1/books/8:fetch", "")
+	assert.JSONEq(t, `{"id":"8"}`, body)
+
+	// body and response body are mapped to field
+	_, body = do(baseURL, http.MethodPost, "/v1/shelves/s1/books", `{"title":"go"}`)
+	assert.JSONEq(t, `{"title":"go"}`, body)
+
+	// nested field is bound to path variable of multiple segments, which overrides body
+	_, body = do(baseURL, http.MethodPatch, "/v1/titles/a%20b/c", `{"shelf":"s2","book":{"title":"x"}}`)
+	assert.JSONEq(t, `{"shelf":"s2","book":{"title":"titles/a b/c"}}`, body)
+
+	// service registered after routes are loaded is routed, its method without annotation is called by
+	// POST /{service}/{method}
+	rsp, _ = do(baseURL, http.MethodPost, "/"+echoServiceName+"/Echo", `"hello"`)
+	assert.Equal(t, http.StatusNotFound, rsp.StatusCode)
+	serviceMap.Store(echoServiceName, &echoService{})
+	rsp, body = do(baseURL, http.MethodPost, "/"+echoServiceName+"/Echo", `"hello"`)
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.JSONEq(t, `"hello"`, body)
+
+	// request body is limited by max request message size of method
+	rsp, _ = do(baseURL, http.MethodPost, "/"+echoServiceName+"/Echo", `"`+strings.Repeat("a", 32)+`"`)
+	assert.Equal(t, http.StatusTooManyRequests, rsp.StatusCode)
+
+	// errors are sent as google.rpc.Status
+	rsp, body = do(baseURL, http.MethodGet, "/v1/shelves/missing/books/1", "")
+	assert.Equal(t, http.StatusNotFound, rsp.StatusCode)
+	assert.JSONEq(t, `{"code":5,"message":"shelf not found"}`, body)
+	rsp, _ = do(baseURL, http.MethodGet, "/v1/shelves/s1/books/x", "")
+	assert.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+	rsp, _ = do(baseURL, http.MethodDelete, "/v1/shelves/s1/books/7", "")
+	assert.Equal(t, http.StatusNotFound, rsp.StatusCode)
+
+	// triple client still works on the same port
+	client, err := NewTripleClient(url, testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	reply := &wrapperspb.StringValue{}
+	assert.Nil(t, client.Request(ctx, "/"+echoServiceName+"/Echo", wrapperspb.String("hi"), reply))
+	assert.Equal(t, "hi", reply.Value)
+
+	// gateway on its own listener
+	separate, _ := startTestServer(t, serviceMap, config.NewTripleOption(config.WithHTTPGateway("127.0.0.1:0")))
+	defer separate.Stop()
+	_, body = do("http://"+separate.gatewayAddr.String(), http.MethodGet, "/v1/books/9:fetch", "")
+	assert.JSONEq(t, `{"id":"9"}`, body)
+}
+
+func TestHTTPGatewayPanic(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(errorServiceName, &errorService{})
+	panics := int32(0)
+	panicHandler := func(ctx context.Context, method string, value interface{}, stack []byte) {
+		atomic.AddInt32(&panics, 1)
+		panic("panic handler panic")
+	}
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(config.WithHTTPGateway(""),
+		config.WithPanicHandler(panicHandler)))
+	defer server.Stop()
+	httpClient := &http.Client{Timeout: time.Second * 3}
+
+	// panic of handler and panic handler are both recovered
+	for i := 0; i < 2; i++ {
+		rsp, err := httpClient.Post("http://"+url.Location+"/"+errorServiceName+"/Fail", "application/json",
+			strings.NewReader(`{"service":"panic"}`))
+		assert.Nil(t, err)
+		body, err := ioutil.ReadAll(rsp.Body)
+		assert.Nil(t, err)
+		rsp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, rsp.StatusCode)
+		assert.Contains(t, string(body), "handler panic")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&panics))
+}
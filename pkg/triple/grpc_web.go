@@ -0,0 +1,417 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+
+	// grpcWebTrailerFlag is the flag of length-prefixed frame that contains trailer in grpc-web response body
+	grpcWebTrailerFlag = 0x80
+
+	// grpcWebMaxAge is the seconds that result of CORS preflight request can be cached by browser
+	grpcWebMaxAge = "86400"
+)
+
+// grpcWebDefaultAllowHeaders are request headers allowed by CORS, if preflight request doesn't specify them
+var grpcWebDefaultAllowHeaders = []string{"content-type", "x-grpc-web", "x-user-agent", "grpc-timeout"}
+
+// grpcWebHandler serves grpc-web requests with @next, the handler of grpc requests, and handles CORS of them.
+// Requests that are not grpc-web ones are passed to @next directly if they are http2 requests.
+type grpcWebHandler struct {
+	next http.Handler
+	// http2Next is @next that rejects requests not over http2
+	http2Next      http.Handler
+	allowedOrigins []string
+}
+
+// newGRPCWebHandler returns http handler that translates grpc-web requests to grpc ones handled by @next,
+// CORS requests are allowed for @allowedOrigins, see corsOrigin
+func newGRPCWebHandler(next http.Handler, allowedOrigins []string) *grpcWebHandler {
+	return &grpcWebHandler{next: next, http2Next: requireHTTP2(next), allowedOrigins: allowedOrigins}
+}
+
+func (g *grpcWebHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		g.servePreflight(w, r, origin)
+		return
+	}
+	contentType := r.Header.Get("Content-Type")
+	if !isGRPCWebContentType(contentType) {
+		g.http2Next.ServeHTTP(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "triple: grpc-web request must be POST", http.StatusMethodNotAllowed)
+		return
+	}
+	allowOrigin, credentials, ok := g.corsOrigin(r)
+	if !ok {
+		http.Error(w, "triple: origin is not allowed", http.StatusForbidden)
+		return
+	}
+
+	text := strings.HasPrefix(contentType, grpcWebTextContentType)
+	req := r.Clone(r.Context())
+	req.Header.Set("Content-Type", grpcContentTypeFromWeb(contentType))
+	if text {
+		req.Body = ioutil.NopCloser(&grpcWebTextReader{src: r.Body})
+	}
+	gw := &grpcWebResponseWriter{w: w, header: make(http.Header), text: text, cors: allowOrigin != ""}
+	setCORSHeaders(w.Header(), allowOrigin, credentials)
+	g.next.ServeHTTP(gw, req)
+	gw.finish()
+}
+
+// servePreflight responds CORS preflight request from @origin
+func (g *grpcWebHandler) servePreflight(w http.ResponseWriter, r *http.Request, origin string) {
+	allowOrigin, credentials, ok := g.corsOrigin(r)
+	if origin == "" || !ok || allowOrigin == "" {
+		http.Error(w, "triple: origin is not allowed", http.StatusForbidden)
+		return
+	}
+	allowHeaders := r.Header.Get("Access-Control-Request-Headers")
+	if allowHeaders == "" {
+		allowHeaders = strings.Join(grpcWebDefaultAllowHeaders, ", ")
+	}
+	setCORSHeaders(w.Header(), allowOrigin, credentials)
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+	w.Header().Set("Access-Control-Max-Age", grpcWebMaxAge)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// corsOrigin returns Access-Control-Allow-Origin of request @r, and whether credentials are allowed.
+// Only origins configured explicitly are allowed with credentials, "*" allows all origins without credentials.
+// Cross-origin requests are denied if no origin is configured, and it returns false if the origin is not allowed.
+// Allow-Origin is empty for request without Origin or from the same origin, which needs no CORS headers.
+func (g *grpcWebHandler) corsOrigin(r *http.Request) (string, bool, bool) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return "", false, true
+	}
+	wildcard := false
+	for _, allowed := range g.allowedOrigins {
+		if allowed == "*" {
+			wildcard = true
+		} else if strings.EqualFold(allowed, origin) {
+			return origin, true, true
+		}
+	}
+	if wildcard {
+		return "*", false, true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return "", false, true
+	}
+	return "", false, false
+}
+
+// setCORSHeaders sets CORS headers of response @header with @allowOrigin, it does nothing if @allowOrigin is empty
+func setCORSHeaders(header http.Header, allowOrigin string, credentials bool) {
+	if allowOrigin == "" {
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", allowOrigin)
+	if credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	header.Add("Vary", "Origin")
+}
+
+// isGRPCWebContentType returns true if @contentType is application/grpc-web, application/grpc-web-text,
+// or one of them with subtype, like application/grpc-web+proto
+func isGRPCWebContentType(contentType string) bool {
+	for _, prefix := range []string{grpcWebTextContentType, grpcWebContentType} {
+		if contentType == prefix || strings.HasPrefix(contentType, prefix+"+") || strings.HasPrefix(contentType, prefix+";") {
+			return true
+		}
+	}
+	return false
+}
+
+// grpcContentTypeFromWeb converts grpc-web @contentType to grpc one with the same subtype
+func grpcContentTypeFromWeb(contentType string) string {
+	prefix := grpcWebContentType
+	if strings.HasPrefix(contentType, grpcWebTextContentType) {
+		prefix = grpcWebTextContentType
+	}
+	return "application/grpc" + strings.TrimPrefix(contentType, prefix)
+}
+
+// grpcWebResponseWriter translates grpc response written by H2Controller to grpc-web one.
+// Trailer fields are not sent as http trailers, but encoded in a length-prefixed frame at the end of body,
+// and the whole body is base64 encoded for grpc-web-text requests.
+type grpcWebResponseWriter struct {
+	w http.ResponseWriter
+	// header is set by H2Controller, it's copied to w when header is written
+	header http.Header
+	text   bool
+	cors   bool
+
+	wroteHeader bool
+	// trailerKeys are canonical keys of trailer fields declared by "Trailer" header
+	trailerKeys map[string]bool
+}
+
+func (gw *grpcWebResponseWriter) Header() http.Header {
+	return gw.header
+}
+
+func (gw *grpcWebResponseWriter) WriteHeader(code int) {
+	if gw.wroteHeader {
+		return
+	}
+	gw.wroteHeader = true
+	gw.trailerKeys = make(map[string]bool)
+	for _, v := range gw.header.Values("Trailer") {
+		for _, k := range strings.Split(v, ",") {
+			gw.trailerKeys[textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(k))] = true
+		}
+	}
+	exposed := make([]string, 0, len(gw.header))
+	for k, v := range gw.header {
+		if gw.isTrailerKey(k) {
+			continue
+		}
+		gw.w.Header()[k] = v
+		exposed = append(exposed, strings.ToLower(k))
+	}
+	contentType := grpcWebContentType
+	if gw.text {
+		contentType = grpcWebTextContentType
+	}
+	gw.w.Header().Set("Content-Type", contentType+strings.TrimPrefix(gw.header.Get("Content-Type"), "application/grpc"))
+	if gw.cors {
+		// trailer fields in body are readable by browser, but only exposed headers are
+		exposed = append(exposed, grpcWebTrailerKeys()...)
+		sort.Strings(exposed)
+		gw.w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposed, ", "))
+	}
+	gw.w.WriteHeader(code)
+}
+
+// grpcWebTrailerKeys returns keys of grpc status fields, which are sent in headers of trailers-only response
+func grpcWebTrailerKeys() []string {
+	return []string{"grpc-status", "grpc-message", "grpc-status-details-bin"}
+}
+
+// isTrailerKey returns true if header field @k is a trailer field, which is declared, or prefixed by TrailerPrefix
+func (gw *grpcWebResponseWriter) isTrailerKey(k string) bool {
+	return k == "Trailer" || strings.HasPrefix(k, http.TrailerPrefix) || gw.trailerKeys[textproto.CanonicalMIMEHeaderKey(k)]
+}
+
+func (gw *grpcWebResponseWriter) Write(data []byte) (int, error) {
+	if !gw.wroteHeader {
+		gw.WriteHeader(http.StatusOK)
+	}
+	if !gw.text {
+		return gw.w.Write(data)
+	}
+	// each write is encoded with padding, and client decodes the concatenated chunks
+	if _, err := gw.w.Write([]byte(base64.StdEncoding.EncodeToString(data))); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (gw *grpcWebResponseWriter) Flush() {
+	if flusher, ok := gw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish writes trailer frame, which contains trailer fields set after header is written.
+// Nothing is written for trailers-only response, whose grpc status is already sent in headers.
+func (gw *grpcWebResponseWriter) finish() {
+	if !gw.wroteHeader {
+		gw.WriteHeader(http.StatusOK)
+	}
+	var block bytes.Buffer
+	keys := make([]string, 0, len(gw.header))
+	for k := range gw.header {
+		if k != "Trailer" && gw.isTrailerKey(k) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := strings.ToLower(strings.TrimPrefix(k, http.TrailerPrefix))
+		for _, v := range gw.header[k] {
+			block.WriteString(name + ": " + v + "\r\n")
+		}
+	}
+	frame := make([]byte, 5, 5+block.Len())
+	frame[0] = grpcWebTrailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(block.Len()))
+	frame = append(frame, block.Bytes()...)
+	if _, err := gw.Write(frame); err == nil {
+		gw.Flush()
+	}
+}
+
+// grpcWebTextReader decodes body of grpc-web-text request from @src, which is base64 encoded.
+// The body may be concatenated base64 chunks, each of which is padded, so it's decoded by quantum of 4 bytes.
+type grpcWebTextReader struct {
+	src io.Reader
+	// pending are encoded bytes less than a quantum, which are not decoded
+	pending []byte
+	// decoded are decoded bytes not read
+	decoded []byte
+	err     error
+}
+
+func (r *grpcWebTextReader) Read(p []byte) (int, error) {
+	for len(r.decoded) == 0 && r.err == nil {
+		buf := make([]byte, 4096)
+		n, err := r.src.Read(buf)
+		r.err = err
+		data := append(r.pending, buf[:n]...)
+		complete := len(data) / 4 * 4
+		decoded, decodeErr := decodeBase64Quanta(data[:complete])
+		if decodeErr != nil {
+			r.err = decodeErr
+			break
+		}
+		r.decoded = decoded
+		r.pending = append([]byte(nil), data[complete:]...)
+	}
+	if len(r.decoded) > 0 {
+		n := copy(p, r.decoded)
+		r.decoded = r.decoded[n:]
+		return n, nil
+	}
+	if r.err == io.EOF && len(r.pending) > 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return 0, r.err
+}
+
+// decodeBase64Quanta decodes base64 @data made up of whole quanta, padding may end any quantum
+func decodeBase64Quanta(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data)/4*3)
+	for len(data) > 0 {
+		// decode to the end of the first padded quantum
+		end := len(data)
+		if i := bytes.IndexByte(data, '='); i >= 0 {
+			end = (i/4 + 1) * 4
+		}
+		dst := make([]byte, base64.StdEncoding.DecodedLen(end))
+		n, err := base64.StdEncoding.Decode(dst, data[:end])
+		if err != nil {
+			return nil, perrors.WithStack(err)
+		}
+		out = append(out, dst[:n]...)
+		data = data[end:]
+	}
+	return out, nil
+}
+
+// http2Preface is the client connection preface of http2
+const http2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// sniffHTTP2 reads the beginning of @conn, and returns true if it's http2 connection preface.
+// The returned conn should be used instead of @conn, as the sniffed bytes are buffered in it.
+func sniffHTTP2(conn net.Conn) (net.Conn, bool, error) {
+	reader := bufio.NewReaderSize(conn, len(http2Preface))
+	for i := 1; i <= len(http2Preface); i++ {
+		peeked, err := reader.Peek(i)
+		if err != nil {
+			return nil, false, err
+		}
+		if peeked[i-1] != http2Preface[i-1] {
+			return &bufferedConn{Conn: conn, reader: reader}, false, nil
+		}
+	}
+	return &bufferedConn{Conn: conn, reader: reader}, true, nil
+}
+
+// bufferedConn is net.Conn whose sniffed bytes are buffered in reader
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// connListener is net.Listener that accepts conns put by server, after they are sniffed to be http/1.1 conns
+type connListener struct {
+	addr      net.Addr
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newConnListener(addr net.Addr) *connListener {
+	return &connListener{addr: addr, conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+// put sends @conn to be accepted, @conn is closed if listener is closed
+func (l *connListener) put(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.closed:
+		conn.Close()
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, perrors.New("triple http/1.1 listener is closed")
+	}
+}
+
+func (l *connListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
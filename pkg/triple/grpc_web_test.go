@@ -0,0 +1,207 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/config"
+)
+
+// grpcWebFrame is a length-prefixed frame in grpc-web body
+type grpcWebFrame struct {
+	flag byte
+	data []byte
+}
+
+func encodeGRPCWebFrame(t *testing.T, m proto.Message) []byte {
+	data, err := proto.Marshal(m)
+	assert.Nil(t, err)
+	frame := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+func decodeGRPCWebFrames(t *testing.T, body []byte) []grpcWebFrame {
+	var frames []grpcWebFrame
+	for len(body) > 0 {
+		assert.True(t, len(body) >= 5)
+		length := int(binary.BigEndian.Uint32(body[1:5]))
+		frames = append(frames, grpcWebFrame{flag: body[0], data: body[5 : 5+length]})
+		body = body[5+length:]
+	}
+	return frames
+}
+
+func TestGRPCWeb(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(echoServiceName, &echoService{})
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(config.WithGRPCWeb("http://allowed.com")))
+	defer server.Stop()
+	baseURL := "http://" + url.Location + "/" + echoServiceName
+	httpClient := &http.Client{Timeout: time.Second * 3}
+	post := func(path, contentType, origin string, body []byte) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, baseURL+path, bytes.NewReader(body))
+		assert.Nil(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-Grpc-Web", "1")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rsp, err := httpClient.Do(req)
+		assert.Nil(t, err)
+		return rsp
+	}
+
+	// CORS preflight
+	preflight := func(origin string) *http.Response {
+		req, err := http.NewRequest(http.MethodOptions, baseURL+"/Echo", nil)
+		assert.Nil(t, err)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web")
+		rsp, err := httpClient.Do(req)
+		assert.Nil(t, err)
+		rsp.Body.Close()
+		return rsp
+	}
+	rsp := preflight("http://allowed.com")
+	assert.Equal(t, http.StatusNoContent, rsp.StatusCode)
+	assert.Equal(t, "http://allowed.com", rsp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rsp.Header.Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "content-type,x-grpc-web", rsp.Header.Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, http.StatusForbidden, preflight("http://denied.com").StatusCode)
+
+	// unary invocation over http/1.1, trailer is sent in the last frame of body
+	rsp = post("/Echo", "application/grpc-web+proto", "http://allowed.com",
+		encodeGRPCWebFrame(t, wrapperspb.String("hello")))
+	body, err := ioutil.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, "HTTP/1.1", rsp.Proto)
+	assert.Equal(t, "application/grpc-web+proto", rsp.Header.Get("Content-Type"))
+	assert.Equal(t, "http://allowed.com", rsp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rsp.Header.Get("Access-Control-Expose-Headers"), "grpc-status")
+	assert.Empty(t, rsp.Trailer)
+	frames := decodeGRPCWebFrames(t, body)
+	assert.Equal(t, 2, len(frames))
+	reply := &wrapperspb.StringValue{}
+	assert.Nil(t, proto.Unmarshal(frames[0].data, reply))
+	assert.Equal(t, "hello", reply.Value)
+	assert.Equal(t, byte(0x80), frames[1].flag)
+	assert.Contains(t, string(frames[1].data), "grpc-status: 0\r\n")
+
+	// server streaming invocation of grpc-web-text, whose request is sent in padded base64 chunks
+	frame := encodeGRPCWebFrame(t, wrapperspb.String("abc"))
+	text := base64.StdEncoding.EncodeToString(frame[:4]) + base64.StdEncoding.EncodeToString(frame[4:])
+	rsp = post("/Split", "application/grpc-web-text", "", []byte(text))
+	body, err = ioutil.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, "application/grpc-web-text+proto", rsp.Header.Get("Content-Type"))
+	decoded, err := decodeBase64Quanta(body)
+	assert.Nil(t, err)
+	frames = decodeGRPCWebFrames(t, decoded)
+	assert.Equal(t, 4, len(frames))
+	for i, want := range []string{"a", "b", "c"} {
+		assert.Nil(t, proto.Unmarshal(frames[i].data, reply))
+		assert.Equal(t, want, reply.Value)
+	}
+	assert.Contains(t, string(frames[3].data), "grpc-status: 0\r\n")
+
+	// error before invocation is sent in headers of trailers-only response
+	rsp = post("/Unknown", "application/grpc-web+proto", "", encodeGRPCWebFrame(t, wrapperspb.String("hello")))
+	body, _ = ioutil.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	assert.Equal(t, "12", rsp.Header.Get("grpc-status"))
+	assert.Empty(t, body)
+
+	// request from origin not allowed and plain grpc request over http/1.1 are rejected
+	rsp = post("/Echo", "application/grpc-web+proto", "http://denied.com", nil)
+	rsp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, rsp.StatusCode)
+	rsp = post("/Echo", "application/grpc+proto", "", nil)
+	rsp.Body.Close()
+	assert.Equal(t, http.StatusUnsupportedMediaType, rsp.StatusCode)
+
+	// triple client still works over http2
+	client, err := NewTripleClient(url, testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	assert.Nil(t, client.Request(ctx, "/"+echoServiceName+"/Echo", wrapperspb.String("hi"), reply))
+	assert.Equal(t, "hi", reply.Value)
+}
+
+func TestGRPCWebTextReader(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("a")) + base64.StdEncoding.EncodeToString([]byte("bcde"))
+	data, err := ioutil.ReadAll(&grpcWebTextReader{src: bytes.NewReader([]byte(encoded))})
+	assert.Nil(t, err)
+	assert.Equal(t, "abcde", string(data))
+
+	_, err = ioutil.ReadAll(&grpcWebTextReader{src: bytes.NewReader([]byte(encoded[:len(encoded)-1]))})
+	assert.NotNil(t, err)
+}
+
+func TestGRPCWebCORSOrigin(t *testing.T) {
+	for _, c := range []struct {
+		allowedOrigins []string
+		origin         string
+		allowOrigin    string
+		credentials    bool
+		ok             bool
+	}{
+		// cross-origin requests are denied if no origin is configured
+		{origin: "http://evil.com", ok: false},
+		{origin: "http://triple.io:8080", ok: true},
+		{ok: true},
+		// "*" allows all origins without credentials
+		{allowedOrigins: []string{"*"}, origin: "http://evil.com", allowOrigin: "*", ok: true},
+		// configured origins are allowed with credentials
+		{allowedOrigins: []string{"*", "http://allowed.com"}, origin: "http://Allowed.com", allowOrigin: "http://Allowed.com", credentials: true, ok: true},
+		{allowedOrigins: []string{"http://allowed.com"}, origin: "http://evil.com", ok: false},
+	} {
+		g := newGRPCWebHandler(http.NotFoundHandler(), c.allowedOrigins)
+		req, err := http.NewRequest(http.MethodPost, "http://triple.io:8080/svc/Method", nil)
+		assert.Nil(t, err)
+		if c.origin != "" {
+			req.Header.Set("Origin", c.origin)
+		}
+		allowOrigin, credentials, ok := g.corsOrigin(req)
+		assert.Equal(t, c.allowOrigin, allowOrigin)
+		assert.Equal(t, c.credentials, credentials)
+		assert.Equal(t, c.ok, ok)
+	}
+}
@@ -0,0 +1,223 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	logger "github.com/dubbogo/gost/dubbogo/logger"
+	gxprotocol "github.com/dubbogo/gost/dubbogo/protocol"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+)
+
+const (
+	// HealthServiceName is the service name of grpc standard health checking protocol
+	HealthServiceName = "grpc.health.v1.Health"
+
+	// healthWatchPath is the path of Watch method of health service
+	healthWatchPath = "/" + HealthServiceName + "/Watch"
+
+	// healthWatchMinBackoff and healthWatchMaxBackoff limit the interval of restarting broken Watch stream
+	healthWatchMinBackoff = time.Second
+	healthWatchMaxBackoff = time.Minute * 2
+)
+
+// builtinService is implemented by services that triple registers itself, such as health service.
+// These services are always served with protobuf, whichever serializer user chooses.
+type builtinService interface {
+	isBuiltinService()
+}
+
+// errHealthServiceShutdown ends Watch stream after NOT_SERVING is sent to client
+var errHealthServiceShutdown = errors.New("health service is shut down")
+
+// healthService is the triple impl of grpc.health.v1.Health, it stores serving status of each service
+type healthService struct {
+	*health.Server
+
+	// shutdown is set to 1 when health service is shut down
+	shutdown int32
+}
+
+// newHealthService returns healthService, with overall serving status SERVING
+func newHealthService() *healthService {
+	return &healthService{
+		Server: health.NewServer(),
+	}
+}
+
+func (h *healthService) isBuiltinService() {}
+
+// Shutdown sets all serving status to NOT_SERVING, and ignores all future status changes.
+// Watch streams are ended after NOT_SERVING is sent, so that they won't block graceful stop.
+func (h *healthService) Shutdown() {
+	atomic.StoreInt32(&h.shutdown, 1)
+	h.Server.Shutdown()
+}
+
+// Resume sets all serving status to SERVING, and accepts all future status changes.
+func (h *healthService) Resume() {
+	atomic.StoreInt32(&h.shutdown, 0)
+	h.Server.Resume()
+}
+
+// isShutdown returns true if health service is shut down
+func (h *healthService) isShutdown() bool {
+	return atomic.LoadInt32(&h.shutdown) == 1
+}
+
+// SetProxyImpl is not used by healthService, as it is called directly
+func (h *healthService) SetProxyImpl(impl gxprotocol.Invoker) {}
+
+// GetProxyImpl returns nil, as healthService is called directly
+func (h *healthService) GetProxyImpl() gxprotocol.Invoker {
+	return nil
+}
+
+// ServiceDesc returns grpc.health.v1.Health service desc
+func (h *healthService) ServiceDesc() *grpc.ServiceDesc {
+	return &healthServiceDesc
+}
+
+func healthCheckHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(healthpb.HealthCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(healthpb.HealthServer).Check(ctx, in)
+}
+
+func healthWatchHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(healthpb.HealthCheckRequest)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	hs := srv.(*healthService)
+	return hs.Watch(in, &healthWatchServer{ServerStream: stream, service: hs})
+}
+
+// healthWatchServer is the impl of healthpb.Health_WatchServer
+type healthWatchServer struct {
+	grpc.ServerStream
+	service *healthService
+}
+
+func (x *healthWatchServer) Send(m *healthpb.HealthCheckResponse) error {
+	if err := x.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	if m.Status == healthpb.HealthCheckResponse_NOT_SERVING && x.service.isShutdown() {
+		return errHealthServiceShutdown
+	}
+	return nil
+}
+
+var healthServiceDesc = grpc.ServiceDesc{
+	ServiceName: HealthServiceName,
+	HandlerType: (*healthpb.HealthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Check",
+			Handler:    healthCheckHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       healthWatchHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "grpc/health/v1/health.proto",
+}
+
+// watchHealth keeps watching serving status of server until client is closed,
+// and it restarts Watch stream with exponential backoff when the stream is broken
+func (t *TripleClient) watchHealth() {
+	backoff := healthWatchMinBackoff
+	for {
+		if t.doWatchHealth() {
+			// stream has received status, so the broken stream is not caused by an unhealthy connection
+			backoff = healthWatchMinBackoff
+		}
+		select {
+		case <-t.closeChan:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > healthWatchMaxBackoff {
+			backoff = healthWatchMaxBackoff
+		}
+	}
+}
+
+// doWatchHealth starts a Watch stream, and refreshes serving status of client until the stream is broken,
+// it returns true if any status is received
+func (t *TripleClient) doWatchHealth() bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientStream, err := t.h2Controller.streamInvoke(ctx, healthWatchPath, codec.NewProtobufCodeC())
+	if err != nil {
+		logger.Warnf("triple client start health watching error = %v", err)
+		return false
+	}
+	if err := clientStream.SendMsg(&healthpb.HealthCheckRequest{Service: t.opt.HealthCheckServiceName}); err != nil {
+		logger.Warnf("triple client send health watching request error = %v", err)
+		return false
+	}
+	received := false
+	for {
+		rsp := &healthpb.HealthCheckResponse{}
+		if err := clientStream.RecvMsg(rsp); err != nil {
+			logger.Warnf("triple client health watching stream of %s broken, error = %v", t.addr, err)
+			return received
+		}
+		received = true
+		serving := rsp.Status == healthpb.HealthCheckResponse_SERVING
+		if !serving {
+			logger.Warnf("triple server %s reports serving status %s", t.addr, rsp.Status)
+		}
+		t.setServing(serving)
+	}
+}
+
+// setServing stores serving status reported by health service of server
+func (t *TripleClient) setServing(serving bool) {
+	if serving {
+		atomic.StoreInt32(&t.notServing, 0)
+		return
+	}
+	atomic.StoreInt32(&t.notServing, 1)
+}
+
+// isServing returns false if health service of server reports not serving
+func (t *TripleClient) isServing() bool {
+	return atomic.LoadInt32(&t.notServing) == 0
+}
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/config"
+)
+
+func TestHealthService(t *testing.T) {
+	server, url := startTestServer(t, &sync.Map{}, nil)
+	defer server.Stop()
+
+	cc, err := grpc.Dial(url.Location, grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer cc.Close()
+	healthClient := healthpb.NewHealthClient(cc)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	rsp, err := healthClient.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, rsp.Status)
+
+	watchClient, err := healthClient.Watch(ctx, &healthpb.HealthCheckRequest{Service: "test"})
+	assert.Nil(t, err)
+	rsp, err = watchClient.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, rsp.Status)
+
+	server.SetServingStatus("test", healthpb.HealthCheckResponse_SERVING)
+	rsp, err = watchClient.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, rsp.Status)
+}
+
+func TestClientHealthCheck(t *testing.T) {
+	server, url := startTestServer(t, &sync.Map{}, nil)
+	server.SetServingStatus("test", healthpb.HealthCheckResponse_SERVING)
+
+	client, err := NewTripleClient(url, nil, config.NewTripleOption(
+		config.WithSerializerType(common.TripleHessianWrapperSerializerName),
+		config.WithHealthCheck("test"),
+	))
+	assert.Nil(t, err)
+	defer client.Close()
+	time.Sleep(time.Millisecond * 200)
+	assert.True(t, client.IsAvailable())
+
+	server.SetServingStatus("test", healthpb.HealthCheckResponse_NOT_SERVING)
+	time.Sleep(time.Millisecond * 200)
+	assert.False(t, client.IsAvailable())
+
+	server.SetServingStatus("test", healthpb.HealthCheckResponse_SERVING)
+	time.Sleep(time.Millisecond * 200)
+	assert.True(t, client.IsAvailable())
+
+	server.GracefulStop()
+	time.Sleep(time.Millisecond * 200)
+	assert.False(t, client.IsAvailable())
+}
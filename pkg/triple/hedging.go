@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+import (
+	logger "github.com/dubbogo/gost/dubbogo/logger"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+// hedgeResult is the result of an attempt of hedged invocation
+type hedgeResult struct {
+	conn    *TripleClient
+	data    []byte
+	trailer http.Header
+	err     error
+}
+
+// hedgeUnary sends serialized request @data to @path every HedgingDelay of @policy, until a response is received.
+// Each attempt prefers a connection that is not tried, so that hedges go to different addresses if there are many.
+// The first successful response is unmarshalled to @reply, and other attempts are canceled with RST_STREAM.
+func (t *TripleClient) hedgeUnary(ctx context.Context, path string, data []byte, reply interface{},
+	policy *config.HedgingPolicy) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts > maxRetryAttempts {
+		maxAttempts = maxRetryAttempts
+	}
+	// canceling ctx resets streams of attempts that are still in flight
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// each attempt sends one result, so sending never blocks
+	results := make(chan hedgeResult, maxAttempts)
+	attempts, pending := 0, 0
+	var tried []*TripleClient
+	startAttempt := func() {
+		attempts++
+		pending++
+		conn, err := t.pick(ctx, path, tried)
+		if err != nil {
+			results <- hedgeResult{err: err}
+			return
+		}
+		tried = append(tried, conn)
+		go func() {
+			rspData, trailer, err := conn.h2Controller.unaryInvoke(ctx, path, data)
+			results <- hedgeResult{conn: conn, data: rspData, trailer: trailer, err: err}
+		}()
+	}
+
+	startAttempt()
+	// stopped is true when no more attempts should be sent
+	stopped := false
+	nextAttempt := time.After(policy.HedgingDelay)
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-nextAttempt:
+			nextAttempt = nil
+			if !stopped && attempts < maxAttempts {
+				startAttempt()
+				nextAttempt = time.After(policy.HedgingDelay)
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				t.retryThrottler.onSuccess()
+				return res.conn.h2Controller.unmarshalResponse(res.data, reply)
+			}
+			if !containsCode(status.Code(res.err), policy.NonFatalStatusCodes) {
+				return res.err
+			}
+			if t.retryThrottler.onFailure() {
+				logger.Warnf("triple hedging is throttled, err = %v", res.err)
+				stopped = true
+			}
+			if pushback := res.trailer.Get(codec.TrailerKeyGrpcRetryPushbackMs); pushback != "" && !stopped {
+				delay, ok := parsePushback(pushback)
+				stopped = !ok
+				nextAttempt = time.After(delay)
+			} else if !stopped && attempts < maxAttempts {
+				// next attempt is sent at once after non-fatal failure
+				logger.Warnf("triple hedged invoke %s failed with err = %v, send next attempt", path, res.err)
+				startAttempt()
+				nextAttempt = time.After(policy.HedgingDelay)
+			}
+			if pending == 0 && (stopped || attempts >= maxAttempts) {
+				return res.err
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+func TestHedging(t *testing.T) {
+	service := newFlakyService(0, 0)
+	serviceMap := &sync.Map{}
+	serviceMap.Store(flakyServiceName, service)
+	server, url := startTestServer(t, serviceMap, nil)
+	defer server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	callPath := "/" + flakyServiceName + "/Call"
+	client, err := NewTripleClient(url, testStubImpl{}, config.NewTripleOption(
+		config.WithMethodConfig(callPath, &config.MethodConfig{
+			HedgingPolicy: &config.HedgingPolicy{
+				MaxAttempts:         3,
+				HedgingDelay:        time.Millisecond * 50,
+				NonFatalStatusCodes: []codes.Code{codes.Unavailable},
+			},
+		}),
+	))
+	assert.Nil(t, err)
+	defer client.Close()
+
+	// slow attempt is hedged after delay, and the hedged one wins
+	service.reset(0, time.Second)
+	start := time.Now()
+	reply := &healthpb.HealthCheckResponse{}
+	assert.Nil(t, client.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, reply))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, reply.Status)
+	assert.True(t, time.Since(start) < time.Millisecond*500)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&service.calls))
+
+	// next attempt is sent at once after non-fatal failure
+	service.reset(1, 0)
+	start = time.Now()
+	assert.Nil(t, client.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, reply))
+	assert.True(t, time.Since(start) < time.Millisecond*50)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&service.calls))
+
+	// all attempts fail
+	service.reset(3, 0)
+	err = client.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, reply)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&service.calls))
+
+	// timeout of method without hedging
+	timeoutClient, err := NewTripleClient(url, testStubImpl{}, config.NewTripleOption(
+		config.WithMethodConfig(callPath, &config.MethodConfig{Timeout: time.Millisecond * 100}),
+	))
+	assert.Nil(t, err)
+	defer timeoutClient.Close()
+	service.reset(0, time.Second)
+	start = time.Now()
+	err = timeoutClient.Request(ctx, callPath, &healthpb.HealthCheckRequest{}, reply)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.True(t, time.Since(start) < time.Millisecond*500)
+}
@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"sync"
+	"testing"
+)
+
+import (
+	dubboCommon "github.com/dubbogo/gost/dubbogo"
+	gxprotocol "github.com/dubbogo/gost/dubbogo/protocol"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/config"
+)
+
+// testService is embedded by pb services of tests, which are invoked by handlers of their grpc.ServiceDesc
+// rather than by proxy impl
+type testService struct{}
+
+func (testService) SetProxyImpl(impl gxprotocol.Invoker) {}
+
+func (testService) GetProxyImpl() gxprotocol.Invoker {
+	return nil
+}
+
+// newTestURL returns triple url with port 0, server started with it listens on a random port,
+// and client created with it can't connect until its location is replaced
+func newTestURL(t *testing.T) *dubboCommon.URL {
+	url, err := dubboCommon.NewURL("tri://127.0.0.1:0/com.apache.dubbo.sample.basic.IGreeter")
+	assert.Nil(t, err)
+	return url
+}
+
+// startTestServer starts triple server with @serviceMap, and returns it with url of the address it listens on
+func startTestServer(t *testing.T, serviceMap *sync.Map, opt *config.Option) (*TripleServer, *dubboCommon.URL) {
+	server := NewTripleServer(newTestURL(t), serviceMap, opt)
+	server.Start()
+	return server, urlWithLocation(newTestURL(t), server.Addr())
+}
@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	dubboConstant "github.com/dubbogo/gost/dubbogo/constant"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+const hessianStreamServiceName = "triple.test.HessianStreamService"
+
+// hessianStreamService is a hessian service with streaming methods, whose messages are strings or int64
+type hessianStreamService struct {
+	testService
+}
+
+func (h *hessianStreamService) ServiceDesc() *grpc.ServiceDesc {
+	return &hessianStreamServiceDesc
+}
+
+var hessianStreamServiceDesc = grpc.ServiceDesc{
+	ServiceName: hessianStreamServiceName,
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			// Split sends each character of request
+			StreamName: "Split",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				var s string
+				if err := stream.RecvMsg(&s); err != nil {
+					return err
+				}
+				for _, c := range s {
+					if err := stream.SendMsg(string(c)); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			ServerStreams: true,
+		},
+		{
+			// Sum sends sum of all requests
+			StreamName: "Sum",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				var sum int64
+				for {
+					var n int64
+					err := stream.RecvMsg(&n)
+					if err == io.EOF {
+						return stream.SendMsg(sum)
+					}
+					if err != nil {
+						return err
+					}
+					sum += n
+				}
+			},
+			ClientStreams: true,
+		},
+		{
+			// Chat echoes each request
+			StreamName: "Chat",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				for {
+					var s string
+					if err := stream.RecvMsg(&s); err != nil {
+						if err == io.EOF {
+							return nil
+						}
+						return err
+					}
+					if err := stream.SendMsg(s); err != nil {
+						return err
+					}
+				}
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+}
+
+func TestHessianStreaming(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(hessianStreamServiceName, &hessianStreamService{})
+	hessianOpt := config.WithSerializerType(common.TripleHessianWrapperSerializerName)
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(hessianOpt))
+	defer server.Stop()
+
+	client, err := NewTripleClient(url, &hessianStreamService{}, config.NewTripleOption(hessianOpt))
+	assert.Nil(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	ctx = context.WithValue(ctx, dubboConstant.DubboCtxKey(dubboConstant.INTERFACE_KEY), hessianStreamServiceName)
+	invokeStream := func(methodName string, args ...interface{}) grpc.ClientStream {
+		in := []reflect.Value{reflect.ValueOf(ctx)}
+		for _, arg := range args {
+			in = append(in, reflect.ValueOf(arg))
+		}
+		rsp := client.Invoke(methodName, in)
+		assert.False(t, rsp[1].IsValid())
+		return rsp[0].Interface().(grpc.ClientStream)
+	}
+
+	// server streaming, request args are sent by Invoke
+	splitStream := invokeStream("Split", []interface{}{"abc"})
+	for _, want := range []string{"a", "b", "c"} {
+		var s string
+		assert.Nil(t, splitStream.RecvMsg(&s))
+		assert.Equal(t, want, s)
+	}
+	var s string
+	assert.Equal(t, io.EOF, splitStream.RecvMsg(&s))
+
+	// server streaming method needs exactly one request
+	rsp := client.Invoke("Split", []reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf("a"), reflect.ValueOf("b")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(rsp[1].Interface().(error)))
+
+	// client streaming
+	sumStream := invokeStream("Sum")
+	for _, n := range []int64{1, 2, 3} {
+		assert.Nil(t, sumStream.SendMsg(n))
+	}
+	assert.Nil(t, sumStream.CloseSend())
+	var sum int64
+	assert.Nil(t, sumStream.RecvMsg(&sum))
+	assert.Equal(t, int64(6), sum)
+
+	// bidirectional streaming by StreamRequest
+	chatStream, err := client.StreamRequest(ctx, "/"+hessianStreamServiceName+"/Chat")
+	assert.Nil(t, err)
+	for _, msg := range []string{"hello", "world"} {
+		assert.Nil(t, chatStream.SendMsg(msg))
+		var reply string
+		assert.Nil(t, chatStream.RecvMsg(&reply))
+		assert.Equal(t, msg, reply)
+	}
+	assert.Nil(t, chatStream.CloseSend())
+	assert.Equal(t, io.EOF, chatStream.RecvMsg(&s))
+}
@@ -20,14 +20,14 @@ package triple
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
+	"encoding/base64"
 	"encoding/binary"
-	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -35,28 +35,37 @@ import (
 	dubboCommon "github.com/dubbogo/gost/dubbogo"
 	logger "github.com/dubbogo/gost/dubbogo/logger"
 
-	h2 "github.com/dubbogo/net/http2"
 	h2Triple "github.com/dubbogo/net/http2/triple"
 
 	perrors "github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 import (
 	"github.com/dubbogo/triple/internal/codec"
-	"github.com/dubbogo/triple/internal/codes"
 	"github.com/dubbogo/triple/internal/message"
-	"github.com/dubbogo/triple/internal/status"
 	"github.com/dubbogo/triple/internal/stream"
 	"github.com/dubbogo/triple/internal/tools"
+	"github.com/dubbogo/triple/pkg/codes"
 	"github.com/dubbogo/triple/pkg/common"
 	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
 )
 
+// frameHeaderLen is length of triple data frame header, 1 byte compressed flag and 4 bytes message length
+const frameHeaderLen = 5
+
+// trailerDrainTimeout is the max time to wait for trailer of an invocation that is given up
+const trailerDrainTimeout = time.Second
+
+// requestBodyAbortDelay is the time for http2 transport to abort writing request body after request is canceled
+const requestBodyAbortDelay = time.Millisecond * 10
+
 // H2Controller is used by dubbo3 client/server, to call http2
 type H2Controller struct {
-	// client stores http2 client
-	client http.Client
+	// conn is the http2 connection of client, which reconnects when connection fails
+	conn *clientConn
 
 	// address stores target ip:port
 	address string
@@ -71,79 +80,73 @@ type H2Controller struct {
 	// rpcServiceMap stores is user impl services
 	rpcServiceMap *sync.Map
 
+	// closeChan is closed once when H2Controller is destroyed
 	closeChan chan struct{}
+	closeOnce sync.Once
 
 	// option is 10M by default
 	option *config.Option
 
 	serializer common.Dubbo3Serializer
-}
 
-// skipHeader is to skip first 5 byte from dataframe with header
-func skipHeader(frameData []byte) ([]byte, uint32) {
-	if len(frameData) < 5 {
-		return []byte{}, 0
-	}
-	lineHeader := frameData[:5]
-	length := binary.BigEndian.Uint32(lineHeader[1:])
-	return frameData[5:], length
+	// invocationsLock guards invocations, draining and invocationsDone
+	invocationsLock sync.Mutex
+	// invocations is the count of running invocations of server, or client
+	invocations int
+	// draining is set when server stops gracefully, new invocations of server are rejected after it's set
+	draining bool
+	// invocationsDone is closed when invocations drop to zero, it's nil if nobody waits for it
+	invocationsDone chan struct{}
+	// inflight is the count of in-flight invocations of client
+	inflight int64
+
+	// loadReporter reports server load in trailers, it's nil if load reporting is disabled
+	loadReporter *loadReporter
+
+	// breakers are client circuit breakers of the endpoint and its methods, nil means no circuit breaker
+	breakers *endpointBreakers
+
+	// loadReport stores the latest *LoadReport that client receives from server
+	loadReport atomic.Value
 }
 
 // readSplitData is called when client want to receive data from server
 // the param @rBody is from http response. readSplitData can read from it. As data from reader is not a block of data,
 // but split data stream, so there needs unpacking and merging logic with split data that receive.
+// Messages with zero length are also sent to the returned chan, as they are valid messages, e.g. empty pb message.
 func (hc *H2Controller) readSplitData(rBody io.ReadCloser) chan message.Message {
 	cbm := make(chan message.Message)
 	go func() {
 		buf := make([]byte, hc.option.BufferSize)
+		// splitBuffer stores received data that is not sent as a whole message yet
+		splitBuffer := bytes.NewBuffer(make([]byte, 0))
 		for {
-			splitBuffer := message.Message{
-				Buffer: bytes.NewBuffer(make([]byte, 0)),
-			}
-
-			// fromFrameHeaderDataSize is wanting data size now
-			fromFrameHeaderDataSize := uint32(0)
-			for {
-				var n int
-				var err error
-				if splitBuffer.Len() < int(fromFrameHeaderDataSize) || splitBuffer.Len() == 0 {
-					n, err = rBody.Read(buf)
+			n, err := rBody.Read(buf)
+			splitBuffer.Write(buf[:n])
+			// send all whole messages in split buffer
+			for splitBuffer.Len() >= frameHeaderLen {
+				// fromFrameHeaderDataSize is wanting data size of current message
+				fromFrameHeaderDataSize := binary.BigEndian.Uint32(splitBuffer.Bytes()[1:frameHeaderLen])
+				if splitBuffer.Len() < frameHeaderLen+int(fromFrameHeaderDataSize) {
+					break
 				}
-
-				if err != nil {
-					cbm <- message.Message{
-						MsgType: message.ServerStreamCloseMsgType,
-					}
-					return
+				splitBuffer.Next(frameHeaderLen)
+				allDataBody := make([]byte, fromFrameHeaderDataSize)
+				copy(allDataBody, splitBuffer.Next(int(fromFrameHeaderDataSize)))
+				cbm <- message.Message{
+					Buffer:  bytes.NewBuffer(allDataBody),
+					MsgType: message.DataMsgType,
 				}
-				splitedData := buf[:n]
-				splitBuffer.Write(splitedData)
-				if fromFrameHeaderDataSize == 0 {
-					// should parse data frame header first
-					data := splitBuffer.Bytes()
-					var totalSize uint32
-					if data, totalSize = skipHeader(data); totalSize == 0 {
-						break
-					} else {
-						// get wanting data size from header
-						fromFrameHeaderDataSize = totalSize
-					}
-					splitBuffer.Reset()
-					splitBuffer.Write(data)
+			}
+			if err != nil {
+				closeMsg := message.Message{
+					MsgType: message.ServerStreamCloseMsgType,
 				}
-				if splitBuffer.Len() >= int(fromFrameHeaderDataSize) {
-					allDataBody := make([]byte, fromFrameHeaderDataSize)
-					_, err := splitBuffer.Read(allDataBody)
-					if err != nil {
-						logger.Errorf("read SplitedDatas error = %v", err)
-					}
-					cbm <- message.Message{
-						Buffer:  bytes.NewBuffer(allDataBody),
-						MsgType: message.DataMsgType,
-					}
-					// temp data is sent, and reset wanting data size
-					fromFrameHeaderDataSize = 0
+				if splitBuffer.Len() > 0 {
+					closeMsg.Status = status.New(codes.Internal, "triple: stream ended with incomplete message")
 				}
+				cbm <- closeMsg
+				return
 			}
 		}
 	}()
@@ -165,22 +168,33 @@ func (hc *H2Controller) GetHandler() func(w http.ResponseWriter, r *http.Request
 			grpcMessage   = ""
 			grpcCode      = 0
 			traceProtoBin = 0
+			// grpcStatus is the full status returned by processor, its details are sent in grpc-status-details-bin
+			grpcStatus *status.Status
 		)
 		// load handler and header
 		headerHandler, _ := common.GetProtocolHeaderHandler(hc.url.Protocol, nil, nil)
 		header := headerHandler.ReadFromTripleReqHeader(r)
 
+		if !hc.acceptInvocation() {
+			writeTrailersOnlyRsp(w, status.Errorf(codes.Unavailable, "triple: server is stopping"))
+			return
+		}
+		defer hc.doneInvocation()
+
 		// new server stream
-		st, err := hc.newServerStreamFromTripleHedaer(header)
+		reqMD := headerToMD(r.Header)
+		st, err := hc.newServerStreamFromTripleHedaer(header, reqMD)
 		if st == nil || err != nil {
 			logger.Errorf("creat server stream error = %v\n", err)
-			rspErrMsg := fmt.Sprintf("creat server stream error = %v\n", err)
-			w.WriteHeader(400)
-			if _, err := w.Write([]byte(rspErrMsg)); err != nil {
-				logger.Errorf("write back rsp error message %s, error", rspErrMsg)
+			if err == nil {
+				err = status.Errorf(codes.Internal, "creat server stream of %s failed", header.GetPath())
 			}
+			writeTrailersOnlyRsp(w, err)
 			return
-			// todo handle interface/method not found error with grpc-status
+		}
+		if hc.loadReporter != nil {
+			hc.loadReporter.incInflight()
+			defer hc.loadReporter.decInflight()
 		}
 		sendChan := st.GetSend()
 		closeChan := make(chan struct{})
@@ -195,6 +209,13 @@ func (hc *H2Controller) GetHandler() func(w http.ResponseWriter, r *http.Request
 					return
 				case msgData := <-ch:
 					if msgData.MsgType == message.ServerStreamCloseMsgType {
+						if msgData.Status != nil {
+							// request is broken, e.g. client cancels before response header
+							st.Close()
+							return
+						}
+						// client has finished sending
+						st.CloseRecv()
 						return
 					}
 					data := hc.pkgHandler.Pkg2FrameData(msgData.Bytes())
@@ -208,8 +229,31 @@ func (hc *H2Controller) GetHandler() func(w http.ResponseWriter, r *http.Request
 		// first response header
 		w.Header().Add("Trailer", codec.TrailerKeyGrpcStatus)
 		w.Header().Add("Trailer", codec.TrailerKeyGrpcMessage)
+		w.Header().Add("Trailer", codec.TrailerKeyGrpcStatusDetailsBin)
 		w.Header().Add("Trailer", codec.TrailerKeyTraceProtoBin)
-		w.Header().Add("content-type", "application/grpc+proto")
+		if hc.loadReporter != nil {
+			w.Header().Add("Trailer", codec.TrailerKeyEndpointLoadMetrics)
+		}
+		// response is serialized in the same format as request
+		w.Header().Add("content-type", "application/grpc+"+contentSubtype(reqMD))
+		// headers are written before the first message, or when handler sends header, or after invocation.
+		// They are written before grpc status fields are set, so that grpc status fields are only sent in trailers,
+		// otherwise client would regard the response without body as a trailers-only response
+		headerWritten := false
+		writeHeader := func(md metadata.MD) {
+			if headerWritten {
+				return
+			}
+			headerWritten = true
+			// content-type of handler overrides the default one, e.g. proxy relays that of backend
+			if contentType := md.Get("content-type"); len(contentType) > 0 {
+				w.Header().Set("content-type", contentType[0])
+			}
+			mdToHeader(md, w.Header(), "")
+			w.WriteHeader(http.StatusOK)
+		}
+		// trailer is set by handler
+		var trailer metadata.MD
 
 		// start receiving response from upper proxy invoker, and forward to remote http2 client
 	LOOP:
@@ -220,9 +264,22 @@ func (hc *H2Controller) GetHandler() func(w http.ResponseWriter, r *http.Request
 				grpcMessage = "triple server canceled by force" // encodeGrpcMessage(sendMsg.st.Message())
 				// call finished by force
 				break LOOP
+			case <-r.Context().Done():
+				// remote client is gone, cancel the stream to let streaming rpc returns
+				st.Close()
+				go drainSendChan(sendChan)
+				grpcCode = int(codes.Canceled)
+				grpcMessage = "triple stream canceled by client"
+				break LOOP
 			case sendMsg := <-sendChan:
+				if sendMsg.MsgType == message.ServerHeaderMsgType {
+					writeHeader(sendMsg.MD)
+					continue
+				}
 				if sendMsg.Buffer == nil || sendMsg.MsgType != message.DataMsgType {
+					trailer = sendMsg.MD
 					if sendMsg.Status != nil {
+						grpcStatus = sendMsg.Status
 						grpcCode = int(sendMsg.Status.Code())
 						grpcMessage = sendMsg.Status.Message()
 						//if sendMsg.Status.Code() != codes.OK {
@@ -232,14 +289,31 @@ func (hc *H2Controller) GetHandler() func(w http.ResponseWriter, r *http.Request
 					// call finished
 					break LOOP
 				}
+				writeHeader(nil)
 				sendData := sendMsg.Bytes()
 				if _, err := w.Write(sendData); err != nil {
 					logger.Errorf(" receiving response from upper proxy invoker error = %v", err)
 				}
+				// flush data to client at once, as streaming rpc may not finish in a short time
+				if flusher, ok := w.(http.Flusher); ok {
+					flusher.Flush()
+				}
 			}
 		}
 
 		// second response header with trailer fields
+		writeHeader(nil)
+		mdToHeader(trailer, w.Header(), http.TrailerPrefix)
+		if st := grpcStatus.Proto(); st != nil && len(st.Details) > 0 {
+			if detailsBin, err := codec.EncodeGrpcStatusDetails(st); err != nil {
+				logger.Errorf("triple server encode grpc status details error = %v", err)
+			} else {
+				w.Header().Set(codec.TrailerKeyGrpcStatusDetailsBin, detailsBin)
+			}
+		}
+		if hc.loadReporter != nil {
+			w.Header().Set(codec.TrailerKeyEndpointLoadMetrics, hc.loadReporter.report().String())
+		}
 		headerHandler.WriteTripleFinalRspHeaderField(w, grpcCode, grpcMessage, traceProtoBin)
 
 		// close all related go routines
@@ -247,6 +321,90 @@ func (hc *H2Controller) GetHandler() func(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// writeTrailersOnlyRsp writes status of @err in a trailers-only response, which has only one HEADERS frame with
+// http status 200, content-type and grpc status. It's used when error occurs before the invocation starts.
+func writeTrailersOnlyRsp(w http.ResponseWriter, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.New(codes.Internal, err.Error())
+	}
+	w.Header().Set("content-type", "application/grpc")
+	w.Header().Set(codec.TrailerKeyGrpcStatus, strconv.Itoa(int(st.Code())))
+	w.Header().Set(codec.TrailerKeyGrpcMessage, codec.EncodeGrpcMessage(st.Message()))
+	if p := st.Proto(); len(p.Details) > 0 {
+		if detailsBin, err := codec.EncodeGrpcStatusDetails(p); err == nil {
+			w.Header().Set(codec.TrailerKeyGrpcStatusDetailsBin, detailsBin)
+		}
+	}
+	// no body is written, so that http2 server sends headers with END_STREAM flag when handler returns
+	w.WriteHeader(http.StatusOK)
+}
+
+// drainSendChan drops all messages from @sendChan until the close message, so that the processor of a canceled
+// stream won't be blocked when it writes response
+func drainSendChan(sendChan <-chan message.Message) {
+	for sendMsg := range sendChan {
+		if sendMsg.MsgType != message.DataMsgType && sendMsg.MsgType != message.ServerHeaderMsgType {
+			return
+		}
+	}
+}
+
+// cancelRequestBody wakes up http2 transport that is writing request body from @sendStreamChan after request is
+// canceled before response header arrives. It works around the limitation of the transport, which waits for message
+// to write request body and can't reset the stream meanwhile, so it's only used by transparent proxy, which must
+// cancel backend invocations of canceled clients. After the transport has aborted writing body, it sends an
+// incomplete message, and the transport resets the stream when it tries to write it. In case the message is written
+// before aborting, the end of body is sent then, so that server cancels the stream as request is broken.
+// It returns when all are sent or @closeChan is closed.
+func cancelRequestBody(sendStreamChan chan<- h2Triple.BufferMsg, closeChan <-chan struct{}) {
+	select {
+	case <-closeChan:
+		// the invocation ends by itself, e.g. the transport returns after response header
+		return
+	case <-time.After(requestBodyAbortDelay):
+	}
+	msgs := []h2Triple.BufferMsg{
+		{Buffer: bytes.NewBuffer([]byte{0xff}), MsgType: h2Triple.DataMsgType},
+		{MsgType: h2Triple.ServerStreamCloseMsgType},
+	}
+	for _, msg := range msgs {
+		select {
+		case sendStreamChan <- msg:
+		case <-closeChan:
+			return
+		}
+	}
+	<-closeChan
+}
+
+// reservedHeaders are header fields that are set by triple or http2, they are not sent from metadata
+var reservedHeaders = map[string]bool{
+	"content-type":                       true,
+	"user-agent":                         true,
+	"te":                                 true,
+	"host":                               true,
+	"connection":                         true,
+	"keep-alive":                         true,
+	"proxy-connection":                   true,
+	"transfer-encoding":                  true,
+	"upgrade":                            true,
+	"content-length":                     true,
+	"trailer":                            true,
+	"grpc-timeout":                       true,
+	"grpc-encoding":                      true,
+	"grpc-accept-encoding":               true,
+	codec.TrailerKeyGrpcStatus:           true,
+	codec.TrailerKeyGrpcMessage:          true,
+	codec.TrailerKeyGrpcStatusDetailsBin: true,
+	codec.TrailerKeyEndpointLoadMetrics:  true,
+}
+
+// isReservedHeader returns true if metadata @key must not be sent as header field
+func isReservedHeader(key string) bool {
+	return strings.HasPrefix(key, ":") || reservedHeaders[strings.ToLower(key)]
+}
+
 // getMethodAndStreamDescMap get unary method desc map and stream method desc map from dubbo3 stub
 func getMethodAndStreamDescMap(ds common.Dubbo3GrpcService) (map[string]grpc.MethodDesc, map[string]grpc.StreamDesc, error) {
 	sdMap := make(map[string]grpc.MethodDesc, 8)
@@ -260,6 +418,21 @@ func getMethodAndStreamDescMap(ds common.Dubbo3GrpcService) (map[string]grpc.Met
 	return sdMap, strMap, nil
 }
 
+// hessianStreamDesc returns StreamDesc of streaming method @methodName in grpc.Desc of hessian @service,
+// it returns false if service has no grpc.Desc or the method is not streaming
+func hessianStreamDesc(service common.Dubbo3GrpcService, methodName string) (grpc.StreamDesc, bool) {
+	desc := service.ServiceDesc()
+	if desc == nil {
+		return grpc.StreamDesc{}, false
+	}
+	for _, streamDesc := range desc.Streams {
+		if streamDesc.StreamName == methodName {
+			return streamDesc, true
+		}
+	}
+	return grpc.StreamDesc{}, false
+}
+
 // NewH2Controller can create H2Controller with impl @rpcServiceMap and url
 // @opt can be nil or configured by user
 func NewH2Controller(isServer bool, rpcServiceMap *sync.Map, url *dubboCommon.URL, opt *config.Option) (*H2Controller, error) {
@@ -275,30 +448,48 @@ func NewH2Controller(isServer bool, rpcServiceMap *sync.Map, url *dubboCommon.UR
 		return nil, err
 	}
 
-	// new http client struct
-	var client http.Client
-	if !isServer {
-		client = http.Client{
-			Transport: &h2.Transport{
-				DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
-					return net.Dial(network, addr)
-				},
-			},
-		}
-	}
-
 	h2c := &H2Controller{
 		url:           url,
-		client:        client,
 		rpcServiceMap: rpcServiceMap,
 		pkgHandler:    pkgHandler,
 		option:        opt,
 		closeChan:     make(chan struct{}),
 		serializer:    serilizer,
 	}
+	if isServer && opt.LoadReport {
+		h2c.loadReporter = newLoadReporter(h2c.closeChan)
+	}
+	if !isServer {
+		h2c.breakers = newEndpointBreakers(opt.CircuitBreaker, url.Location)
+		h2c.conn = newClientConn(url.Location, opt.GetConnectBackoff(), nil)
+	}
 	return h2c, nil
 }
 
+// loadService finds service with @interfaceKey from rpcServiceMap,
+// if not found, it finds service whose grpc service name is @interfaceKey, as grpc tools call services by service name
+func (hc *H2Controller) loadService(interfaceKey string) (common.Dubbo3GrpcService, error) {
+	serviceInterface, ok := hc.rpcServiceMap.Load(interfaceKey)
+	if !ok {
+		hc.rpcServiceMap.Range(func(_, value interface{}) bool {
+			if service, isService := value.(common.Dubbo3GrpcService); isService &&
+				service.ServiceDesc() != nil && service.ServiceDesc().ServiceName == interfaceKey {
+				serviceInterface, ok = value, true
+				return false
+			}
+			return true
+		})
+	}
+	if !ok {
+		return nil, status.Err(codes.Unimplemented, "not found target service key "+interfaceKey)
+	}
+	service, ok := serviceInterface.(common.Dubbo3GrpcService)
+	if !ok {
+		return nil, status.Err(codes.Internal, "can't assert impl of interface "+interfaceKey+" to dubbo RPCService")
+	}
+	return service, nil
+}
+
 /*
 newServerStreamFromTripleHedaer can create a serverStream by @data read from frame, after receiving a request from client.
 
@@ -306,38 +497,48 @@ firstly, it checks and gets calling params interfaceKey and methodName and use i
 secondly, it judge if it is streaming rpc or unary rpc
 thirdly, new stream and return
 
-any error occurs in the above procedures are fatal, as the invocation target can't be found.
+any error occurs in the above procedures are fatal, as the invocation target can't be found,
+unless UnknownServiceHandler is set to handle invocations of unregistered services and methods, with request metadata @reqMD.
 todo how to deal with error in this procedure gracefully is to be discussed next
 */
-func (hc *H2Controller) newServerStreamFromTripleHedaer(data h2Triple.ProtocolHeader) (stream.Stream, error) {
+func (hc *H2Controller) newServerStreamFromTripleHedaer(data h2Triple.ProtocolHeader, reqMD metadata.MD) (stream.Stream, error) {
 	interfaceKey, methodName, err := tools.GetServiceKeyAndUpperCaseMethodNameFromPath(data.GetPath())
 	if err != nil {
 		return nil, err
 	}
 
-	serviceInterface, ok := hc.rpcServiceMap.Load(interfaceKey)
-	if !ok {
-		return nil, status.Err(codes.Unimplemented, "not found target service key"+interfaceKey)
-	}
-	service, ok := serviceInterface.(common.Dubbo3GrpcService)
-	if !ok {
-		return nil, status.Err(codes.Internal, "can't assert impl of interface "+interfaceKey+" to dubbo RPCService")
+	service, err := hc.loadService(interfaceKey)
+	if status.Code(err) == codes.Unimplemented {
+		return hc.newUnknownServiceStream(data, reqMD, err)
+	} else if err != nil {
+		return nil, err
 	}
 
 	var newstm stream.Stream
 
+	// serializer is chosen for each request, so that the same server serves clients of different serializers
+	opt, serializer, err := hc.negotiateSerializer(service, methodName, reqMD)
+	if err != nil {
+		return nil, err
+	}
+
 	// creat server stream
-	switch hc.option.SerializerType {
+	switch opt.SerializerType {
 	case common.TripleHessianWrapperSerializerName:
-		// hessian serializer doesn't need to use grpc.Desc, and now only support unary invocation
+		// hessian serializer doesn't need to use grpc.Desc for unary invocation, which is invoked by proxy impl,
+		// and streaming methods are found in grpc.Desc of service, whose messages are wrapped one by one
 		var err error
-		newstm, err = stream.NewUnaryServerStreamWithOutDesc(data, hc.url, service, hc.serializer, hc.option)
+		if streamDesc, ok := hessianStreamDesc(service, methodName); ok {
+			newstm, err = stream.NewServerStream(data, streamDesc, hc.url, service, serializer, opt)
+		} else {
+			newstm, err = stream.NewUnaryServerStreamWithOutDesc(data, hc.url, service, serializer, opt)
+		}
 		if err != nil {
 			logger.Errorf("hessian server new server stream error = %v", err)
 			return nil, err
 		}
-	case common.PBSerializerName:
-		// pb serializer needs grpc.Desc to do method discovery, allowing unary and streaming invocation
+	case common.PBSerializerName, common.JSONSerializerName:
+		// pb and json serializers need grpc.Desc to do method discovery, allowing unary and streaming invocation
 		mdMap, strMap, err := getMethodAndStreamDescMap(service)
 		if err != nil {
 			logger.Error("new H2 controller error:", err)
@@ -347,25 +548,25 @@ func (hc *H2Controller) newServerStreamFromTripleHedaer(data h2Triple.ProtocolHe
 		streamd, oks := strMap[methodName]
 		if !okm && !oks {
 			logger.Errorf("method name %s not found in desc\n", methodName)
-			return nil, status.Err(codes.Unimplemented, "method name %s not found in desc")
+			return hc.newUnknownServiceStream(data, reqMD, status.Errorf(codes.Unimplemented, "method name %s not found in desc", methodName))
 		}
 
 		if okm {
-			newstm, err = stream.NewServerStream(data, md, hc.url, service, hc.serializer, hc.option)
+			newstm, err = stream.NewServerStream(data, md, hc.url, service, serializer, opt)
 			if err != nil {
 				logger.Error("newServerStream error", err)
 				return nil, err
 			}
 		} else {
-			newstm, err = stream.NewServerStream(data, streamd, hc.url, service, hc.serializer, hc.option)
+			newstm, err = stream.NewServerStream(data, streamd, hc.url, service, serializer, opt)
 			if err != nil {
 				logger.Error("newServerStream error", err)
 				return nil, err
 			}
 		}
 	default:
-		logger.Errorf("http2 controller serializer type = %s is invalid", hc.option.SerializerType)
-		return nil, perrors.Errorf("http2 controller serializer type = %s is invalid", hc.option.SerializerType)
+		logger.Errorf("http2 controller serializer type = %s is invalid", opt.SerializerType)
+		return nil, perrors.Errorf("http2 controller serializer type = %s is invalid", opt.SerializerType)
 	}
 
 	return newstm, nil
@@ -374,17 +575,51 @@ func (hc *H2Controller) newServerStreamFromTripleHedaer(data h2Triple.ProtocolHe
 
 // StreamInvoke can start streaming invocation, called by triple client, with @path
 func (hc *H2Controller) StreamInvoke(ctx context.Context, path string) (grpc.ClientStream, error) {
+	return hc.streamInvoke(ctx, path, hc.serializer)
+}
+
+// streamInvoke starts streaming invocation with @path, messages of the stream are serialized by @serializer.
+// It fails fast with Unavailable if circuit breaker is open. The http2 stream is reset by transport when @ctx is
+// done after response header arrives, while transport can't reset it before that, see cancelRequestBody.
+func (hc *H2Controller) streamInvoke(ctx context.Context, path string, serializer common.Dubbo3Serializer) (grpc.ClientStream, error) {
+	return hc.startStream(ctx, path, serializer, false)
+}
+
+// proxyStreamInvoke starts streaming invocation like streamInvoke, whose http2 stream is reset by cancelRequestBody
+// if @ctx is done before response header arrives
+func (hc *H2Controller) proxyStreamInvoke(ctx context.Context, path string, serializer common.Dubbo3Serializer) (grpc.ClientStream, error) {
+	return hc.startStream(ctx, path, serializer, true)
+}
+
+// startStream starts streaming invocation of streamInvoke, request body is aborted by cancelRequestBody
+// if @abortBody is true
+func (hc *H2Controller) startStream(ctx context.Context, path string, serializer common.Dubbo3Serializer,
+	abortBody bool) (grpc.ClientStream, error) {
+	done, err := hc.breakers.allow(path)
+	if err != nil {
+		return nil, err
+	}
+	maxRequestBytes, maxResponseBytes := hc.maxMessageBytes(path)
 	clientStream := stream.NewClientStream()
+	hc.startInvocation()
 
 	tosend := clientStream.GetSend()
 	sendStreamChan := make(chan h2Triple.BufferMsg)
 	closeChan := make(chan struct{})
+	var canceled <-chan struct{}
+	if abortBody {
+		canceled = ctx.Done()
+	}
 	go func() {
 		for {
 			select {
 			case <-closeChan:
 				clientStream.Close()
 				return
+			case <-canceled:
+				cancelRequestBody(sendStreamChan, closeChan)
+				clientStream.Close()
+				return
 			case sendMsg := <-tosend:
 				sendStreamChan <- h2Triple.BufferMsg{
 					Buffer:  bytes.NewBuffer(sendMsg.Bytes()),
@@ -399,37 +634,80 @@ func (hc *H2Controller) StreamInvoke(ctx context.Context, path string) (grpc.Cli
 		Handler:  headerHandler,
 	}
 	go func() {
-		rsp, err := hc.client.Post("https://"+hc.address+path, "application/grpc+proto", &stremaReq)
+		// latency of stream is not tracked, as it may last for a long time
+		defer func() {
+			done(clientStream.GetRecvStatus().Err(), 0)
+			hc.finishInvocation()
+		}()
+		rsp, err := hc.post(ctx, path, serializer, &stremaReq)
 		if err != nil {
 			logger.Errorf("http2 request error = %s", err)
 			// close send stream and return
+			clientStream.CloseRecvWithStatus(status.Convert(err))
+			close(closeChan)
+			return
+		}
+		if st, ok := statusFromTrailersOnlyRsp(rsp); ok {
+			// stream may end without any message, with status OK
+			if st.Code() != codes.OK {
+				logger.Errorf("grpc status not success,msg = %s, code = %d", st.Message(), st.Code())
+			}
+			clientStream.SetRecvTrailer(headerToMD(rsp.Header))
+			clientStream.CloseRecvWithStatus(st)
 			close(closeChan)
 			return
 		}
+		clientStream.SetRecvHeader(headerToMD(rsp.Header))
 		ch := hc.readSplitData(rsp.Body)
 	LOOP:
 		for {
 			select {
 			case <-hc.closeChan:
+				clientStream.CloseRecvWithStatus(status.New(codes.Canceled, "triple client canceled by force"))
+				close(closeChan)
+				return
+			case <-ctx.Done():
+				// the http2 stream is reset by transport
+				clientStream.CloseRecvWithStatus(status.FromContextError(ctx.Err()))
 				close(closeChan)
+				return
 			case data := <-ch:
 				if data.Buffer == nil || data.MsgType == message.ServerStreamCloseMsgType {
-					// stream receive done, close send go routine
-					close(closeChan)
+					// stream receive done
 					break LOOP
 				}
+				if maxResponseBytes > 0 && data.Len() > maxResponseBytes {
+					// reset the http2 stream
+					rsp.Body.Close()
+					go drainTrailer(rsp.Body.(*h2Triple.ResponseBody).GetTrailerChan())
+					clientStream.CloseRecvWithStatus(status.Newf(codes.ResourceExhausted,
+						"triple: received message larger than max (%d vs. %d)", data.Len(), maxResponseBytes))
+					close(closeChan)
+					return
+				}
 				pkg := hc.pkgHandler.Pkg2FrameData(data.Bytes())
 				clientStream.PutRecv(pkg, message.DataMsgType)
 			}
 
 		}
-		trailer := rsp.Body.(*h2Triple.ResponseBody).GetTrailer()
-		code, _ := strconv.Atoi(trailer.Get(codec.TrailerKeyGrpcStatus))
-		msg := trailer.Get(codec.TrailerKeyGrpcMessage)
-		if codes.Code(code) != codes.OK {
-			logger.Errorf("grpc status not success,msg = %s, code = %d", msg, code)
+		trailer, err := waitTrailer(ctx, rsp)
+		if err != nil {
+			clientStream.CloseRecvWithStatus(status.Convert(err))
+			close(closeChan)
+			return
 		}
-
+		hc.storeLoadReport(trailer)
+		st, err := statusFromTrailer(trailer)
+		if err != nil {
+			st = status.New(codes.Internal, err.Error())
+		}
+		if st.Code() != codes.OK {
+			logger.Errorf("grpc status not success,msg = %s, code = %d", st.Message(), st.Code())
+		}
+		// let user stream returns final status, and close send go routine
+		clientStream.SetRecvTrailer(headerToMD(trailer))
+		clientStream.CloseRecvWithStatus(st)
+		close(closeChan)
 	}()
 
 	pkgHandler, err := common.GetPackagerHandler(hc.url.Protocol)
@@ -437,17 +715,95 @@ func (hc *H2Controller) StreamInvoke(ctx context.Context, path string) (grpc.Cli
 		logger.Errorf("triple get package handler error = %v", err)
 		return nil, err
 	}
-	return stream.NewClientUserStream(clientStream, hc.serializer, pkgHandler), nil
+	return stream.NewClientUserStream(ctx, clientStream, serializer, pkgHandler, maxRequestBytes), nil
 }
 
-// UnaryInvoke can start unary invocation, called by dubbo3 client, with @path and request @data
+// UnaryInvoke can start unary invocation, called by dubbo3 client, with @path and request @arg
 func (hc *H2Controller) UnaryInvoke(ctx context.Context, path string, arg, reply interface{}) error {
 	data, err := hc.serializer.MarshalRequest(arg)
 	if err != nil {
 		logger.Errorf("client request marshal error = %v", err)
 		return err
 	}
+	rspData, _, err := hc.unaryInvoke(ctx, path, data)
+	if err != nil {
+		return err
+	}
+	return hc.unmarshalResponse(rspData, reply)
+}
+
+// unmarshalResponse unmarshals response @data of unary invocation to @reply
+func (hc *H2Controller) unmarshalResponse(data []byte, reply interface{}) error {
+	if err := hc.serializer.UnmarshalResponse(data, reply); err != nil {
+		logger.Errorf("client unmarshal rsp err= %v\n", err)
+		return err
+	}
+	return nil
+}
+
+// post sends streaming request @req with payload serialized by @serializer to @path, the http2 stream is reset
+// when @ctx is done. Outgoing metadata of @ctx is sent as header fields.
+// It waits for ready connection if method config of @path enables WaitForReady, otherwise it fails fast
+// with Unavailable when connection is in transient failure.
+func (hc *H2Controller) post(ctx context.Context, path string, serializer common.Dubbo3Serializer,
+	req *h2Triple.StreamingRequest) (*http.Response, error) {
+	mc := hc.option.GetMethodConfig(path)
+	cc, err := hc.conn.getConn(ctx, mc != nil && mc.WaitForReady)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+hc.address+path, req)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "triple new request error = %v", err)
+	}
+	httpReq.Header.Set("Content-Type", common.GetContentType(serializer))
+	if _, ok := serializer.(*codec.TripleHessianWrapperSerializer); ok {
+		httpReq.Header.Set(codec.TripleSerializer, string(common.TripleHessianWrapperSerializerName))
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		mdToHeader(md, httpReq.Header, "")
+	}
+	rsp, err := cc.RoundTrip(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, status.Errorf(codes.Unavailable, "triple invoke error = %v", err)
+	}
+	return rsp, nil
+}
+
+// unaryInvoke starts unary invocation with @path and serialized request @data, so that @data can be sent again
+// when retrying or hedging. It returns serialized response, and trailer of response if it's received.
+// It fails fast with Unavailable if circuit breaker is open.
+func (hc *H2Controller) unaryInvoke(ctx context.Context, path string, data []byte) ([]byte, http.Header, error) {
+	if maxRequestBytes, _ := hc.maxMessageBytes(path); maxRequestBytes > 0 && len(data) > maxRequestBytes {
+		return nil, nil, status.Errorf(codes.ResourceExhausted, "triple: trying to send message larger than max (%d vs. %d)",
+			len(data), maxRequestBytes)
+	}
+	done, err := hc.breakers.allow(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	hc.startInvocation()
+	defer hc.finishInvocation()
+	start := time.Now()
+	rspData, trailer, err := hc.doUnaryInvoke(ctx, path, data)
+	done(err, time.Since(start))
+	return rspData, trailer, err
+}
+
+// maxMessageBytes returns limits of request and response message size of method with @path, zero means no limit
+func (hc *H2Controller) maxMessageBytes(path string) (int, int) {
+	mc := hc.option.GetMethodConfig(path)
+	if mc == nil {
+		return 0, 0
+	}
+	return mc.MaxRequestMessageBytes, mc.MaxResponseMessageBytes
+}
 
+// doUnaryInvoke sends serialized request @data to @path, and returns serialized response and trailer
+func (hc *H2Controller) doUnaryInvoke(ctx context.Context, path string, data []byte) ([]byte, http.Header, error) {
 	sendStreamChan := make(chan h2Triple.BufferMsg, 2)
 
 	headerHandler, _ := common.GetProtocolHeaderHandler(hc.url.Protocol, hc.url, ctx)
@@ -468,10 +824,17 @@ func (hc *H2Controller) UnaryInvoke(ctx context.Context, path string, arg, reply
 		Handler:  headerHandler,
 	}
 
-	rsp, err := hc.client.Post("https://"+hc.address+path, "application/grpc+proto", &stremaReq)
+	rsp, err := hc.post(ctx, path, hc.serializer, &stremaReq)
 	if err != nil {
 		logger.Errorf("triple unary invoke error = %v", err)
-		return err
+		return nil, nil, err
+	}
+	if st, ok := statusFromTrailersOnlyRsp(rsp); ok {
+		if st.Code() == codes.OK {
+			return nil, rsp.Header, status.Err(codes.Internal, "unary response ends without message")
+		}
+		logger.Errorf("grpc status not success, msg = %s, code = %d", st.Message(), st.Code())
+		return nil, rsp.Header, st.Err()
 	}
 
 	readBuf := make([]byte, hc.option.BufferSize)
@@ -484,7 +847,9 @@ func (hc *H2Controller) UnaryInvoke(ctx context.Context, path string, arg, reply
 	// todo make timeout configurable
 	timeoutTicker := time.After(time.Second * time.Duration(int(hc.option.Timeout)))
 	timeoutFlag := false
+	// readCloseChain is closed when invocation returns, to stop reading loop below
 	readCloseChain := make(chan struct{})
+	defer close(readCloseChain)
 
 	fromFrameHeaderDataSize := uint32(0)
 
@@ -492,23 +857,24 @@ func (hc *H2Controller) UnaryInvoke(ctx context.Context, path string, arg, reply
 
 	go func() {
 		for {
-			select {
-			case <-readCloseChain:
-				return
-			default:
-			}
 			n, err := rsp.Body.Read(readBuf)
-			if err != nil {
-				if err.Error() != "EOF" {
-					logger.Errorf("dubbo3 unary invoke read error = %v\n", err)
+			if n > 0 {
+				splitedData := make([]byte, n)
+				copy(splitedData, readBuf[:n])
+				select {
+				case <-readCloseChain:
 					return
+				case splitedDataChain <- message.Message{
+					Buffer: bytes.NewBuffer(splitedData),
+				}:
 				}
-				continue
 			}
-			splitedData := make([]byte, n)
-			copy(splitedData, readBuf[:n])
-			splitedDataChain <- message.Message{
-				Buffer: bytes.NewBuffer(splitedData),
+			if err != nil {
+				// body is read to the end, status is waited from trailer
+				if err != io.EOF {
+					logger.Errorf("dubbo3 unary invoke read error = %v\n", err)
+				}
+				return
 			}
 		}
 	}()
@@ -526,20 +892,26 @@ LOOP:
 				// should parse data frame header first
 				var totalSize uint32
 				if splitedData, totalSize = hc.pkgHandler.Frame2PkgData(splitedData); totalSize == 0 {
-					return nil
+					return nil, nil, nil
 				} else {
 					fromFrameHeaderDataSize = totalSize
 				}
+				if _, maxResponseBytes := hc.maxMessageBytes(path); maxResponseBytes > 0 && int(totalSize) > maxResponseBytes {
+					// reset the http2 stream
+					rsp.Body.Close()
+					go drainTrailer(trailerChan)
+					return nil, nil, status.Errorf(codes.ResourceExhausted, "triple: received message larger than max (%d vs. %d)",
+						totalSize, maxResponseBytes)
+				}
 				splitBuffer.Reset()
 			}
 			splitBuffer.Write(splitedData)
 			if splitBuffer.Len() > int(fromFrameHeaderDataSize) {
 				logger.Error("dubbo3 unary invoke error = Receive Splited Data is bigger than wanted.")
-				return perrors.New("dubbo3 unary invoke error = Receive Splited Data is bigger than wanted.")
+				return nil, nil, perrors.New("dubbo3 unary invoke error = Receive Splited Data is bigger than wanted.")
 			}
 
 			if splitBuffer.Len() == int(fromFrameHeaderDataSize) {
-				close(readCloseChain)
 				break LOOP
 			}
 		case tra := <-trailerChan:
@@ -550,9 +922,12 @@ LOOP:
 				break LOOP
 			}
 
+		case <-ctx.Done():
+			// the http2 stream is reset, trailer may never arrive
+			go drainTrailer(trailerChan)
+			return nil, nil, status.FromContextError(ctx.Err()).Err()
+
 		case <-timeoutTicker:
-			// close reading loop ablove
-			close(readCloseChain)
 			// set timeout flag
 			timeoutFlag = true
 			break LOOP
@@ -561,46 +936,260 @@ LOOP:
 
 	if timeoutFlag {
 		logger.Errorf("unary call %s timeout", path)
-		return perrors.Errorf("unary call %s timeout", path)
+		go drainTrailer(trailerChan)
+		return nil, nil, status.Errorf(codes.DeadlineExceeded, "unary call %s timeout", path)
 	}
 
 	// todo start ticker to avoid trailer timeout
 	if !recvTrailer {
 		// if not receive err trailer, wait until recv
-		trailer = rsp.Body.(*h2Triple.ResponseBody).GetTrailer()
+		if trailer, err = waitTrailer(ctx, rsp); err != nil {
+			return nil, nil, err
+		}
 	}
+	hc.storeLoadReport(trailer)
 
-	code, err := strconv.Atoi(trailer.Get(codec.TrailerKeyGrpcStatus))
+	st, err := statusFromTrailer(trailer)
 	if err != nil {
 		logger.Errorf("get trailer err = %v", err)
-		return perrors.Errorf("get trailer err = %v", err)
+		return nil, trailer, err
+	}
+	if st.Code() != codes.OK {
+		logger.Errorf("grpc status not success, msg = %s, code = %d", st.Message(), st.Code())
+		return nil, trailer, st.Err()
 	}
-	msg := trailer.Get(codec.TrailerKeyGrpcMessage)
 
-	if codes.Code(code) != codes.OK {
-		logger.Errorf("grpc status not success, msg = %s, code = %d", msg, code)
-		return perrors.Errorf("grpc status not success, msg = %s, code = %d", msg, code)
+	// all split data are collected
+	return splitBuffer.Bytes(), trailer, nil
+}
+
+// waitTrailer waits trailer of @rsp, it returns error if @ctx is done first
+func waitTrailer(ctx context.Context, rsp *http.Response) (http.Header, error) {
+	trailerChan := rsp.Body.(*h2Triple.ResponseBody).GetTrailerChan()
+	select {
+	case trailer := <-trailerChan:
+		return trailer, nil
+	case <-ctx.Done():
+		go drainTrailer(trailerChan)
+		return nil, status.FromContextError(ctx.Err()).Err()
 	}
+}
 
-	// all split data are collected and to unmarshal
-	if err := hc.serializer.UnmarshalResponse(splitBuffer.Bytes(), reply); err != nil {
-		logger.Errorf("client unmarshal rsp err= %v\n", err)
-		return err
+// drainTrailer receives trailer that may be being sent when invocation is given up,
+// as http2 read loop of the connection blocks until trailer is received
+func drainTrailer(trailerChan <-chan http.Header) {
+	timer := time.NewTimer(trailerDrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-trailerChan:
+	case <-timer.C:
 	}
-	return nil
 }
 
-// Destroy destroys H2Controller and force close all related goroutine
+// statusFromTrailersOnlyRsp returns status of @rsp and true, if @rsp is a trailers-only response whose status is
+// in headers, or it isn't a valid grpc response. Otherwise status is sent in trailers after body, and false is returned.
+func statusFromTrailersOnlyRsp(rsp *http.Response) (*status.Status, bool) {
+	if rsp.StatusCode != http.StatusOK {
+		return status.Newf(httpStatusToCode(rsp.StatusCode), "unexpected http status %s", rsp.Status), true
+	}
+	if rsp.Header.Get(codec.TrailerKeyGrpcStatus) == "" {
+		return nil, false
+	}
+	st, err := statusFromTrailer(rsp.Header)
+	if err != nil {
+		return status.New(codes.Internal, err.Error()), true
+	}
+	return st, true
+}
+
+// httpStatusToCode maps http status to grpc code, as grpc http2 spec defines
+func httpStatusToCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// headerToMD converts http @header to metadata, with lower case keys, values of binary keys ending with "-bin"
+// are decoded from base64
+func headerToMD(header http.Header) metadata.MD {
+	md := make(metadata.MD, len(header))
+	for k, v := range header {
+		if !strings.HasSuffix(strings.ToLower(k), "-bin") {
+			md.Append(k, v...)
+			continue
+		}
+		for _, value := range v {
+			if decoded, err := decodeBinHeader(value); err == nil {
+				value = string(decoded)
+			}
+			md.Append(k, value)
+		}
+	}
+	return md
+}
+
+// decodeBinHeader decodes value of binary header field, which is base64 encoded with or without padding
+func decodeBinHeader(v string) ([]byte, error) {
+	if len(v)%4 == 0 {
+		return base64.StdEncoding.DecodeString(v)
+	}
+	return base64.RawStdEncoding.DecodeString(v)
+}
+
+// mdToHeader sets @md to http @header with keys prefixed by @keyPrefix, reserved keys are skipped,
+// and values of binary keys ending with "-bin" are encoded to base64
+func mdToHeader(md metadata.MD, header http.Header, keyPrefix string) {
+	for k, v := range md {
+		if isReservedHeader(k) {
+			continue
+		}
+		if strings.HasSuffix(k, "-bin") {
+			encoded := make([]string, 0, len(v))
+			for _, value := range v {
+				encoded = append(encoded, base64.RawStdEncoding.EncodeToString([]byte(value)))
+			}
+			v = encoded
+		}
+		header[keyPrefix+k] = v
+	}
+}
+
+// statusFromTrailer returns status that server sends in @trailer.
+// If there is grpc-status-details-bin field, which contains details of status, the status is decoded from it.
+func statusFromTrailer(trailer http.Header) (*status.Status, error) {
+	code, err := strconv.Atoi(trailer.Get(codec.TrailerKeyGrpcStatus))
+	if err != nil {
+		return nil, perrors.Errorf("get trailer err = %v", err)
+	}
+	if detailsBin := trailer.Get(codec.TrailerKeyGrpcStatusDetailsBin); detailsBin != "" {
+		st, err := codec.DecodeGrpcStatusDetails(detailsBin)
+		if err != nil {
+			logger.Warnf("triple client decode grpc status details error = %v", err)
+		} else if st.Code != int32(code) {
+			logger.Warnf("grpc status details code %d doesn't match grpc status code %d", st.Code, code)
+		} else {
+			return status.FromProto(st), nil
+		}
+	}
+	return status.New(codes.Code(code), codec.DecodeGrpcMessage(trailer.Get(codec.TrailerKeyGrpcMessage))), nil
+}
+
+// storeLoadReport stores load report in @trailer from server, if there is one
+func (hc *H2Controller) storeLoadReport(trailer http.Header) {
+	value := trailer.Get(codec.TrailerKeyEndpointLoadMetrics)
+	if value == "" {
+		return
+	}
+	report, err := parseLoadReport(value)
+	if err != nil {
+		logger.Warnf("triple client parse load report error = %v", err)
+		return
+	}
+	hc.loadReport.Store(report)
+}
+
+// getLoadReport returns the latest load report from server, or nil if there is none
+func (hc *H2Controller) getLoadReport() *LoadReport {
+	report, _ := hc.loadReport.Load().(*LoadReport)
+	return report
+}
+
+// startInvocation counts a client invocation that starts
+func (hc *H2Controller) startInvocation() {
+	hc.invocationsLock.Lock()
+	hc.invocations++
+	hc.invocationsLock.Unlock()
+	atomic.AddInt64(&hc.inflight, 1)
+}
+
+// finishInvocation counts a client invocation that finishes
+func (hc *H2Controller) finishInvocation() {
+	atomic.AddInt64(&hc.inflight, -1)
+	hc.doneInvocation()
+}
+
+// acceptInvocation counts a server invocation that starts, it returns false if server is draining
+func (hc *H2Controller) acceptInvocation() bool {
+	hc.invocationsLock.Lock()
+	defer hc.invocationsLock.Unlock()
+	if hc.draining {
+		return false
+	}
+	hc.invocations++
+	return true
+}
+
+// doneInvocation counts an invocation that finishes, and wakes up waiter when there is no running invocation
+func (hc *H2Controller) doneInvocation() {
+	hc.invocationsLock.Lock()
+	defer hc.invocationsLock.Unlock()
+	hc.invocations--
+	if hc.invocations == 0 && hc.invocationsDone != nil {
+		close(hc.invocationsDone)
+		hc.invocationsDone = nil
+	}
+}
+
+// startDraining makes server reject new invocations with codes.Unavailable
+func (hc *H2Controller) startDraining() {
+	hc.invocationsLock.Lock()
+	hc.draining = true
+	hc.invocationsLock.Unlock()
+}
+
+// getInflight returns the count of in-flight invocations of client
+func (hc *H2Controller) getInflight() int64 {
+	return atomic.LoadInt64(&hc.inflight)
+}
+
+// waitInvocationsDone waits for all running invocations to finish, at most @timeout
+func (hc *H2Controller) waitInvocationsDone(timeout time.Duration) {
+	hc.invocationsLock.Lock()
+	if hc.invocations == 0 {
+		hc.invocationsLock.Unlock()
+		return
+	}
+	if hc.invocationsDone == nil {
+		hc.invocationsDone = make(chan struct{})
+	}
+	done := hc.invocationsDone
+	hc.invocationsLock.Unlock()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logger.Warnf("triple invocations are not done in %s", timeout)
+	}
+}
+
+// Destroy destroys H2Controller and force close all related goroutine, connection of client is shut down
+// It can be called more than once.
 func (hc *H2Controller) Destroy() {
-	close(hc.closeChan)
+	hc.closeOnce.Do(func() {
+		close(hc.closeChan)
+		if hc.conn != nil {
+			hc.conn.close()
+		}
+	})
 }
 
+// IsAvailable returns false if controller is destroyed, connection of client is in transient failure,
+// or circuit breaker of the endpoint is open
 func (hc *H2Controller) IsAvailable() bool {
 	select {
 	case <-hc.closeChan:
 		return false
 	default:
-		return true
+		return (hc.conn == nil || hc.conn.isAvailable()) && !hc.breakers.isOpen()
 	}
-	// todo check if controller's http client is available
 }
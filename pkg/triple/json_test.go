@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/config"
+)
+
+// grpcJSONCodec is grpc codec with content-subtype json, which uses triple json serializer
+type grpcJSONCodec struct {
+	codec.JSONCodeC
+}
+
+func (c grpcJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.MarshalRequest(v)
+}
+
+func (c grpcJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return c.UnmarshalResponse(data, v)
+}
+
+func (c grpcJSONCodec) Name() string {
+	return "json"
+}
+
+func TestJSONSerializer(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(echoServiceName, &echoService{})
+	opt := config.NewTripleOption(config.WithSerializerType(common.JSONSerializerName))
+	server, url := startTestServer(t, serviceMap, opt)
+	defer server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	// triple client
+	client, err := NewTripleClient(url, testStubImpl{}, config.NewTripleOption(config.WithSerializerType(common.JSONSerializerName)))
+	assert.Nil(t, err)
+	defer client.Close()
+	reply := &wrapperspb.StringValue{}
+	assert.Nil(t, client.Request(ctx, "/"+echoServiceName+"/Echo", wrapperspb.String("hello"), reply))
+	assert.Equal(t, "hello", reply.Value)
+	stream, err := client.StreamRequest(ctx, "/"+echoServiceName+"/Split")
+	assert.Nil(t, err)
+	assert.Nil(t, stream.SendMsg(wrapperspb.String("ab")))
+	for _, want := range []string{"a", "b"} {
+		assert.Nil(t, stream.RecvMsg(reply))
+		assert.Equal(t, want, reply.Value)
+	}
+
+	// grpc client with content-subtype json
+	encoding.RegisterCodec(grpcJSONCodec{})
+	cc, err := grpc.Dial(url.Location, grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer cc.Close()
+	reply = &wrapperspb.StringValue{}
+	err = cc.Invoke(ctx, "/"+echoServiceName+"/Echo", wrapperspb.String("grpc"), reply, grpc.CallContentSubtype("json"))
+	assert.Nil(t, err)
+	assert.Equal(t, "grpc", reply.Value)
+}
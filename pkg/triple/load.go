@@ -0,0 +1,149 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/syscall"
+)
+
+const (
+	// loadSampleInterval is the interval of sampling cpu utilization of server process
+	loadSampleInterval = time.Second
+
+	// load metrics are reported in ORCA text format: "TEXT cpu_utilization=0.25, named_metrics.inflight=3"
+	loadMetricsPrefix  = "TEXT "
+	loadMetricCPU      = "cpu_utilization"
+	loadMetricInflight = "named_metrics.inflight"
+)
+
+// LoadReport is the load of server, which is reported in trailers of each invocation
+type LoadReport struct {
+	// CPUUtilization is the cpu utilization of server process in last sample interval, ranging [0, 1]
+	CPUUtilization float64
+
+	// Inflight is the count of running invocations of server, including the reporting one
+	Inflight int64
+
+	// ReceivedAt is the time when client receives the report
+	ReceivedAt time.Time
+}
+
+// String returns @r in ORCA text format
+func (r *LoadReport) String() string {
+	return fmt.Sprintf("%s%s=%.4f, %s=%d", loadMetricsPrefix, loadMetricCPU, r.CPUUtilization, loadMetricInflight, r.Inflight)
+}
+
+// parseLoadReport parses load report in ORCA text format, unknown metrics are ignored
+func parseLoadReport(value string) (*LoadReport, error) {
+	if !strings.HasPrefix(value, loadMetricsPrefix) {
+		return nil, perrors.Errorf("invalid load report %q", value)
+	}
+	report := &LoadReport{ReceivedAt: time.Now()}
+	for _, metric := range strings.Split(strings.TrimPrefix(value, loadMetricsPrefix), ",") {
+		kv := strings.SplitN(strings.TrimSpace(metric), "=", 2)
+		if len(kv) != 2 {
+			return nil, perrors.Errorf("invalid load metric %q", metric)
+		}
+		var err error
+		switch kv[0] {
+		case loadMetricCPU:
+			report.CPUUtilization, err = strconv.ParseFloat(kv[1], 64)
+		case loadMetricInflight:
+			report.Inflight, err = strconv.ParseInt(kv[1], 10, 64)
+		}
+		if err != nil {
+			return nil, perrors.Errorf("invalid load metric %q, error = %v", metric, err)
+		}
+	}
+	return report, nil
+}
+
+// loadReporter samples cpu utilization of server process, and counts in-flight invocations of server
+type loadReporter struct {
+	// cpuUtilization stores float64 bits of latest sampled cpu utilization
+	cpuUtilization uint64
+	inflight       int64
+}
+
+// newLoadReporter returns loadReporter, which starts sampling until @closeChan is closed
+func newLoadReporter(closeChan chan struct{}) *loadReporter {
+	l := &loadReporter{}
+	go l.run(closeChan)
+	return l
+}
+
+// run samples cpu utilization every loadSampleInterval until @closeChan is closed
+func (l *loadReporter) run(closeChan chan struct{}) {
+	ticker := time.NewTicker(loadSampleInterval)
+	defer ticker.Stop()
+	lastCPUTime, lastSampleTime := syscall.GetCPUTime(), time.Now()
+	for {
+		select {
+		case <-closeChan:
+			return
+		case now := <-ticker.C:
+			cpuTime := syscall.GetCPUTime()
+			l.setCPUUtilization(cpuUtilization(cpuTime-lastCPUTime, now.Sub(lastSampleTime), runtime.NumCPU()))
+			lastCPUTime, lastSampleTime = cpuTime, now
+		}
+	}
+}
+
+// cpuUtilization returns cpu utilization of process in @elapsed wall time, during which process used @cpuTime nanoseconds
+func cpuUtilization(cpuTime int64, elapsed time.Duration, numCPU int) float64 {
+	if elapsed <= 0 || numCPU <= 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, float64(cpuTime)/float64(elapsed.Nanoseconds())/float64(numCPU)))
+}
+
+func (l *loadReporter) setCPUUtilization(utilization float64) {
+	atomic.StoreUint64(&l.cpuUtilization, math.Float64bits(utilization))
+}
+
+// incInflight is called when server starts an invocation
+func (l *loadReporter) incInflight() {
+	atomic.AddInt64(&l.inflight, 1)
+}
+
+// decInflight is called when server finishes an invocation
+func (l *loadReporter) decInflight() {
+	atomic.AddInt64(&l.inflight, -1)
+}
+
+// report returns current load of server
+func (l *loadReporter) report() *LoadReport {
+	return &LoadReport{
+		CPUUtilization: math.Float64frombits(atomic.LoadUint64(&l.cpuUtilization)),
+		Inflight:       atomic.LoadInt64(&l.inflight),
+	}
+}
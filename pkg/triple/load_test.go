@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadReport(t *testing.T) {
+	report := &LoadReport{CPUUtilization: 0.25, Inflight: 3}
+	assert.Equal(t, "TEXT cpu_utilization=0.2500, named_metrics.inflight=3", report.String())
+
+	parsed, err := parseLoadReport(report.String())
+	assert.Nil(t, err)
+	assert.Equal(t, 0.25, parsed.CPUUtilization)
+	assert.Equal(t, int64(3), parsed.Inflight)
+
+	parsed, err = parseLoadReport("TEXT cpu_utilization=0.5, mem_utilization=0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, parsed.CPUUtilization)
+
+	_, err = parseLoadReport("cpu_utilization=0.5")
+	assert.NotNil(t, err)
+	_, err = parseLoadReport("TEXT cpu_utilization=high")
+	assert.NotNil(t, err)
+}
+
+func TestCPUUtilization(t *testing.T) {
+	assert.Equal(t, 0.5, cpuUtilization(int64(time.Second), time.Second, 2))
+	assert.Equal(t, 1.0, cpuUtilization(int64(time.Second*4), time.Second, 2))
+	assert.Equal(t, 0.0, cpuUtilization(int64(time.Second), 0, 2))
+}
+
+func TestWeightedBalancer(t *testing.T) {
+	idle := &TripleClient{h2Controller: &H2Controller{}}
+	busy := &TripleClient{h2Controller: &H2Controller{}}
+	idle.h2Controller.loadReport.Store(&LoadReport{CPUUtilization: 0.1, Inflight: 1, ReceivedAt: time.Now()})
+	busy.h2Controller.loadReport.Store(&LoadReport{CPUUtilization: 0.9, Inflight: 4, ReceivedAt: time.Now()})
+
+	balancer, err := buildBalancer(WeightedBalancerName)
+	assert.Nil(t, err)
+	idleCount := 0
+	for i := 0; i < 1000; i++ {
+		if balancer.Pick("", []*TripleClient{idle, busy}) == idle {
+			idleCount++
+		}
+	}
+	// weight of idle is 0.9, and weight of busy is 0.025
+	assert.True(t, idleCount > 900)
+
+	// stale report is ignored
+	busy.h2Controller.loadReport.Store(&LoadReport{CPUUtilization: 0.9, Inflight: 4, ReceivedAt: time.Now().Add(-loadReportTTL * 2)})
+	assert.Equal(t, 1.0, loadWeight(busy.LoadReport()))
+}
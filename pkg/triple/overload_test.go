@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	dubboCommon "github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/proxy/proxy_factory"
+	dubboProtocol "github.com/apache/dubbo-go/protocol"
+	dubboConstant "github.com/dubbogo/gost/dubbogo/constant"
+	gxprotocol "github.com/dubbogo/gost/dubbogo/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+const overloadServiceName = "triple.test.OverloadService"
+
+// OverloadService implements overloaded java methods sayHello(String) and sayHello(String, int),
+// it's exported as dubbo-go only registers exported services
+type OverloadService struct {
+	proxyImpl gxprotocol.Invoker
+}
+
+func (s *OverloadService) Reference() string {
+	return overloadServiceName
+}
+
+func (s *OverloadService) SetProxyImpl(impl gxprotocol.Invoker) {
+	s.proxyImpl = impl
+}
+
+func (s *OverloadService) GetProxyImpl() gxprotocol.Invoker {
+	return s.proxyImpl
+}
+
+func (s *OverloadService) ServiceDesc() *grpc.ServiceDesc {
+	return &grpc.ServiceDesc{ServiceName: overloadServiceName, HandlerType: (*interface{})(nil)}
+}
+
+// MethodMapper maps overloads to distinct names, so that dubbo-go registers both of them
+func (s *OverloadService) MethodMapper() map[string]string {
+	return map[string]string{
+		"SayHelloName": "sayHello",
+		"SayHelloAge":  "sayHello(java.lang.String,int)",
+	}
+}
+
+func (s *OverloadService) SayHelloName(ctx context.Context, name string) (string, error) {
+	return "hello " + name, nil
+}
+
+func (s *OverloadService) SayHelloAge(ctx context.Context, name string, age int32) (string, error) {
+	return fmt.Sprintf("hello %s of %d", name, age), nil
+}
+
+// dubboProxyInvoker adapts proxy invoker of dubbo-go, which invokes methods of service by their registered names
+type dubboProxyInvoker struct {
+	invoker dubboProtocol.Invoker
+}
+
+func (p *dubboProxyInvoker) Invoke(ctx context.Context, inv interface{}) gxprotocol.Result {
+	return p.invoker.Invoke(ctx, inv.(dubboProtocol.Invocation))
+}
+
+func TestOverloadDispatch(t *testing.T) {
+	service := &OverloadService{}
+	methods, err := dubboCommon.ServiceMap.Register(overloadServiceName, "tri", "", "", service)
+	assert.Nil(t, err)
+	assert.Equal(t, "sayHello(java.lang.String,int),sayHello", methods)
+	defer dubboCommon.ServiceMap.UnRegister(overloadServiceName, "tri", dubboCommon.ServiceKey(overloadServiceName, "", ""))
+	providerURL, err := dubboCommon.NewURL("tri://127.0.0.1:20000/" + overloadServiceName)
+	assert.Nil(t, err)
+	service.SetProxyImpl(&dubboProxyInvoker{invoker: proxy_factory.NewDefaultProxyFactory().GetInvoker(providerURL)})
+
+	serviceMap := &sync.Map{}
+	serviceMap.Store(overloadServiceName, service)
+	hessianOpt := config.WithSerializerType(common.TripleHessianWrapperSerializerName)
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(hessianOpt))
+	defer server.Stop()
+	client, err := NewTripleClient(url, nil, config.NewTripleOption(hessianOpt))
+	assert.Nil(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	ctx = context.WithValue(ctx, dubboConstant.DubboCtxKey(dubboConstant.INTERFACE_KEY), overloadServiceName)
+
+	path := "/" + overloadServiceName + "/sayHello"
+	for _, c := range []struct {
+		args     []interface{}
+		expected string
+		code     codes.Code
+	}{
+		{args: []interface{}{"dubbo"}, expected: "hello dubbo"},
+		{args: []interface{}{"dubbo", int32(12)}, expected: "hello dubbo of 12"},
+		{args: []interface{}{common.JavaArg{Type: "java.lang.Object", Value: "dubbo"}}, code: codes.Unimplemented},
+	} {
+		var out codec.HessianUnmarshalStruct
+		err := client.Request(ctx, path, c.args, &out)
+		if c.code != codes.OK {
+			assert.Equal(t, c.code, status.Code(err))
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, out.Val)
+	}
+}
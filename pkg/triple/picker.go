@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// loadReportTTL is the time after which a load report is ignored, so that an endpoint getting little traffic
+	// because of high load is probed again with default weight
+	loadReportTTL = time.Second * 30
+
+	// maxCPUUtilizationWeighted limits cpu utilization used in weight, so that a busy endpoint still gets some traffic
+	maxCPUUtilizationWeighted = 0.95
+)
+
+// weightedBalancer picks connection randomly, weighted by load reported by servers.
+// Servers with high cpu utilization or many in-flight invocations get less traffic.
+type weightedBalancer struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newWeightedBalancer() Balancer {
+	return &weightedBalancer{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (b *weightedBalancer) Pick(path string, conns []*TripleClient) *TripleClient {
+	weights := make([]float64, 0, len(conns))
+	total := 0.0
+	for _, c := range conns {
+		weight := loadWeight(c.LoadReport())
+		weights = append(weights, weight)
+		total += weight
+	}
+
+	b.mu.Lock()
+	r := b.rand.Float64() * total
+	b.mu.Unlock()
+	for i, weight := range weights {
+		if r < weight {
+			return conns[i]
+		}
+		r -= weight
+	}
+	return conns[len(conns)-1]
+}
+
+// loadWeight returns weight of endpoint with load @report, in (0, 1].
+// Endpoint without recent report gets the max weight 1.
+func loadWeight(report *LoadReport) float64 {
+	if report == nil || time.Since(report.ReceivedAt) > loadReportTTL {
+		return 1
+	}
+	cpu := report.CPUUtilization
+	if cpu > maxCPUUtilizationWeighted {
+		cpu = maxCPUUtilizationWeighted
+	}
+	if cpu < 0 {
+		cpu = 0
+	}
+	inflight := report.Inflight
+	if inflight < 1 {
+		inflight = 1
+	}
+	return (1 - cpu) / float64(inflight)
+}
@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+// StreamDirector picks backend for invocation of @fullMethodName, e.g. "/grpc.health.v1.Health/Check".
+// @ctx has request header fields as incoming metadata, and the same ones as outgoing metadata which are sent to
+// backend. It returns ctx of backend invocation, which may be @ctx with outgoing metadata changed, and backend client.
+// Error returned by director is sent to client as status.
+type StreamDirector func(ctx context.Context, fullMethodName string) (context.Context, *TripleClient, error)
+
+// NewTransparentProxyHandler returns UnknownServiceHandler that forwards invocations to backends picked by @director.
+// Messages are forwarded without being deserialized, header, messages, trailer and status of backend are relayed
+// to client, and backend invocation is canceled when client cancels. Backend invocations are not retried.
+// It's set to server by config.WithUnknownServiceHandler.
+func NewTransparentProxyHandler(director StreamDirector) grpc.StreamHandler {
+	return func(srv interface{}, serverStream grpc.ServerStream) error {
+		method, ok := grpc.MethodFromServerStream(serverStream)
+		if !ok {
+			return status.Err(codes.Internal, "triple proxy can't get method of stream")
+		}
+		// canceling ctx resets backend stream
+		ctx, cancel := context.WithCancel(serverStream.Context())
+		defer cancel()
+		md, _ := metadata.FromIncomingContext(ctx)
+		backendCtx, backend, err := director(metadata.NewOutgoingContext(ctx, md.Copy()), method)
+		if err != nil {
+			return err
+		}
+		clientStream, err := backend.rawStreamRequest(backendCtx, method, codec.NewRawCodeCWithSubtype(contentSubtype(md)))
+		if err != nil {
+			return err
+		}
+
+		reqErrChan, rspErrChan := make(chan error, 1), make(chan error, 1)
+		go func() {
+			reqErrChan <- forwardRequests(serverStream, clientStream)
+		}()
+		go func() {
+			rspErrChan <- forwardResponses(clientStream, serverStream)
+		}()
+		for {
+			select {
+			case err := <-reqErrChan:
+				if err != nil {
+					// client is gone or request can't be sent to backend
+					return err
+				}
+				// all requests are forwarded, wait for responses
+				reqErrChan = nil
+			case err := <-rspErrChan:
+				return err
+			}
+		}
+	}
+}
+
+// forwardRequests forwards messages from client @src to backend @dst, until client finishes sending or
+// backend finishes the stream
+func forwardRequests(src grpc.ServerStream, dst grpc.ClientStream) error {
+	for {
+		var payload []byte
+		if err := src.RecvMsg(&payload); err == io.EOF {
+			return dst.CloseSend()
+		} else if err != nil {
+			return err
+		}
+		if err := dst.SendMsg(payload); err == io.EOF {
+			// backend has finished, its status is got by forwardResponses
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// forwardResponses forwards header, messages and trailer from backend @src to client @dst,
+// it returns final status of backend
+func forwardResponses(src grpc.ClientStream, dst grpc.ServerStream) error {
+	header, err := src.Header()
+	if err != nil {
+		// trailers-only response
+		dst.SetTrailer(src.Trailer())
+		return err
+	}
+	if err := dst.SendHeader(header); err != nil {
+		return err
+	}
+	for {
+		var payload []byte
+		if err := src.RecvMsg(&payload); err != nil {
+			dst.SetTrailer(src.Trailer())
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := dst.SendMsg(payload); err != nil {
+			return err
+		}
+	}
+}
+
+// contentSubtype returns content-subtype of content-type in @md, e.g. "json" of "application/grpc+json",
+// default one is "proto"
+func contentSubtype(md metadata.MD) string {
+	if contentType := md.Get("content-type"); len(contentType) > 0 {
+		subtype := strings.TrimPrefix(contentType[0], "application/grpc+")
+		if i := strings.IndexByte(subtype, ';'); i >= 0 {
+			subtype = subtype[:i]
+		}
+		if subtype != contentType[0] && subtype != "" {
+			return subtype
+		}
+	}
+	return "proto"
+}
+
+// rawStreamRequest starts streaming invocation of @path, whose messages are raw payloads of @serializer
+func (t *TripleClient) rawStreamRequest(ctx context.Context, path string, serializer common.Dubbo3Serializer) (grpc.ClientStream, error) {
+	conn, err := t.pick(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return conn.h2Controller.proxyStreamInvoke(ctx, path, serializer)
+}
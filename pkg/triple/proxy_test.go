@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+func TestTransparentProxy(t *testing.T) {
+	waiting, canceled := make(chan string, 2), make(chan string, 2)
+	// backend handles echoService, and unregistered methods with raw payloads:
+	// Meta echoes request metadata in header, sends trailer and fails, Wait blocks until it's canceled,
+	// and WaitReply echoes request before that
+	backendHandler := func(srv interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		if strings.HasPrefix(method, "/triple.test.Meta/Wait") {
+			if method == "/triple.test.Meta/WaitReply" {
+				var payload []byte
+				assert.Nil(t, stream.RecvMsg(&payload))
+				assert.Nil(t, stream.SendMsg(payload))
+			}
+			waiting <- method
+			<-stream.Context().Done()
+			canceled <- method
+			return nil
+		}
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		assert.Nil(t, stream.SendHeader(metadata.Pairs("x-echo-bin", md.Get("x-data-bin")[0])))
+		var payload []byte
+		assert.Nil(t, stream.RecvMsg(&payload))
+		assert.Nil(t, stream.SendMsg(payload))
+		stream.SetTrailer(metadata.Pairs("x-trailer", "done"))
+		return status.Err(codes.NotFound, "not found")
+	}
+	serviceMap := &sync.Map{}
+	serviceMap.Store(echoServiceName, &echoService{})
+	backendServer, backendURL := startTestServer(t, serviceMap,
+		config.NewTripleOption(config.WithUnknownServiceHandler(backendHandler)))
+	defer backendServer.Stop()
+	backend, err := NewTripleClient(backendURL, testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer backend.Close()
+
+	director := func(ctx context.Context, fullMethodName string) (context.Context, *TripleClient, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if len(md.Get("x-tenant")) == 0 {
+			return nil, nil, status.Err(codes.PermissionDenied, "no tenant")
+		}
+		return ctx, backend, nil
+	}
+	proxyServer, proxyURL := startTestServer(t, &sync.Map{},
+		config.NewTripleOption(config.WithUnknownServiceHandler(NewTransparentProxyHandler(director))))
+	defer proxyServer.Stop()
+	client, err := NewTripleClient(proxyURL, testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	tenantCtx := metadata.AppendToOutgoingContext(ctx, "x-tenant", "a")
+
+	// director rejects invocation
+	reply := &wrapperspb.StringValue{}
+	err = client.Request(ctx, "/"+echoServiceName+"/Echo", wrapperspb.String("hello"), reply)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// unary and bidirectional streaming invocations
+	assert.Nil(t, client.Request(tenantCtx, "/"+echoServiceName+"/Echo", wrapperspb.String("hello"), reply))
+	assert.Equal(t, "hello", reply.Value)
+	stream, err := client.StreamRequest(tenantCtx, "/"+echoServiceName+"/Chat")
+	assert.Nil(t, err)
+	for _, value := range []string{"a", "b"} {
+		assert.Nil(t, stream.SendMsg(wrapperspb.String(value)))
+		assert.Nil(t, stream.RecvMsg(reply))
+		assert.Equal(t, value, reply.Value)
+	}
+	assert.Nil(t, stream.CloseSend())
+	assert.Equal(t, io.EOF, stream.RecvMsg(reply))
+
+	// header, trailer and status of backend are relayed
+	metaCtx := metadata.AppendToOutgoingContext(tenantCtx, "x-data-bin", "\x00\x01")
+	stream, err = client.StreamRequest(metaCtx, "/triple.test.Meta/Call")
+	assert.Nil(t, err)
+	assert.Nil(t, stream.SendMsg(wrapperspb.String("meta")))
+	header, err := stream.Header()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"\x00\x01"}, header.Get("x-echo-bin"))
+	assert.Nil(t, stream.RecvMsg(reply))
+	assert.Equal(t, "meta", reply.Value)
+	err = stream.RecvMsg(reply)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, []string{"done"}, stream.Trailer().Get("x-trailer"))
+
+	// backend invocation is canceled when client cancels after response header, as transport resets the stream
+	waitCtx, waitCancel := context.WithCancel(tenantCtx)
+	stream, err = client.StreamRequest(waitCtx, "/triple.test.Meta/WaitReply")
+	assert.Nil(t, err)
+	assert.Nil(t, stream.SendMsg(wrapperspb.String("wait")))
+	assert.Nil(t, stream.RecvMsg(reply))
+	assert.Equal(t, "wait", reply.Value)
+	assert.Equal(t, "/triple.test.Meta/WaitReply", <-waiting)
+	waitCancel()
+	assertCanceled := func(method string) {
+		select {
+		case m := <-canceled:
+			assert.Equal(t, method, m)
+		case <-ctx.Done():
+			t.Fatalf("backend invocation %s is not canceled", method)
+		}
+	}
+	assertCanceled("/triple.test.Meta/WaitReply")
+
+	// backend invocation is canceled by proxy when client resets the stream before response header
+	cc, err := grpc.Dial(proxyURL.Location, grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer cc.Close()
+	waitCtx, waitCancel = context.WithCancel(tenantCtx)
+	grpcStream, err := cc.NewStream(waitCtx, &grpc.StreamDesc{ClientStreams: true, ServerStreams: true},
+		"/triple.test.Meta/Wait")
+	assert.Nil(t, err)
+	assert.Nil(t, grpcStream.SendMsg(wrapperspb.String("wait")))
+	assert.Equal(t, "/triple.test.Meta/Wait", <-waiting)
+	waitCancel()
+	assertCanceled("/triple.test.Meta/Wait")
+}
@@ -0,0 +1,271 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+import (
+	gxprotocol "github.com/dubbogo/gost/dubbogo/protocol"
+
+	perrors "github.com/pkg/errors"
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+)
+
+// ReflectionServiceName is the service name of grpc server reflection protocol
+const ReflectionServiceName = "grpc.reflection.v1alpha.ServerReflection"
+
+// reflectionService is the triple impl of grpc.reflection.v1alpha.ServerReflection,
+// it lists services in rpcServiceMap, and finds file descriptors of them from global protobuf registry
+type reflectionService struct {
+	rpcServiceMap *sync.Map
+}
+
+// newReflectionService returns reflectionService that reflects services in @rpcServiceMap
+func newReflectionService(rpcServiceMap *sync.Map) *reflectionService {
+	return &reflectionService{
+		rpcServiceMap: rpcServiceMap,
+	}
+}
+
+func (r *reflectionService) isBuiltinService() {}
+
+// SetProxyImpl is not used by reflectionService, as it is called directly
+func (r *reflectionService) SetProxyImpl(impl gxprotocol.Invoker) {}
+
+// GetProxyImpl returns nil, as reflectionService is called directly
+func (r *reflectionService) GetProxyImpl() gxprotocol.Invoker {
+	return nil
+}
+
+// ServiceDesc returns grpc.reflection.v1alpha.ServerReflection service desc
+func (r *reflectionService) ServiceDesc() *grpc.ServiceDesc {
+	return &reflectionServiceDesc
+}
+
+// serviceDescs returns desc of all services in rpcServiceMap
+func (r *reflectionService) serviceDescs() []*grpc.ServiceDesc {
+	descs := make([]*grpc.ServiceDesc, 0, 8)
+	r.rpcServiceMap.Range(func(_, value interface{}) bool {
+		if service, ok := value.(common.Dubbo3GrpcService); ok && service.ServiceDesc() != nil {
+			descs = append(descs, service.ServiceDesc())
+		}
+		return true
+	})
+	return descs
+}
+
+// listServices returns full names of all services in rpcServiceMap
+func (r *reflectionService) listServices() []*rpb.ServiceResponse {
+	names := make(map[string]struct{})
+	for _, desc := range r.serviceDescs() {
+		names[desc.ServiceName] = struct{}{}
+	}
+	rsp := make([]*rpb.ServiceResponse, 0, len(names))
+	for name := range names {
+		rsp = append(rsp, &rpb.ServiceResponse{Name: name})
+	}
+	sort.Slice(rsp, func(i, j int) bool {
+		return rsp[i].Name < rsp[j].Name
+	})
+	return rsp
+}
+
+// fileDescContainingSymbol finds file descriptor that defines @name in global protobuf registry.
+// If @name is not registered, e.g. service desc is written by hand, the file named by ServiceDesc.Metadata is used.
+func (r *reflectionService) fileDescContainingSymbol(name string) (protoreflect.FileDescriptor, error) {
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err == nil {
+		return desc.ParentFile(), nil
+	}
+	for _, serviceDesc := range r.serviceDescs() {
+		if serviceDesc.ServiceName != name {
+			continue
+		}
+		if fileName, ok := serviceDesc.Metadata.(string); ok {
+			return protoregistry.GlobalFiles.FindFileByPath(fileName)
+		}
+	}
+	return nil, err
+}
+
+// fileDescContainingExtension finds file descriptor that defines extension @number of message @typeName
+func (r *reflectionService) fileDescContainingExtension(typeName string, number int32) (protoreflect.FileDescriptor, error) {
+	extType, err := protoregistry.GlobalTypes.FindExtensionByNumber(protoreflect.FullName(typeName), protoreflect.FieldNumber(number))
+	if err != nil {
+		return nil, err
+	}
+	return extType.TypeDescriptor().ParentFile(), nil
+}
+
+// allExtensionNumbersOfType returns numbers of all registered extensions of message @typeName
+func (r *reflectionService) allExtensionNumbersOfType(typeName string) ([]int32, error) {
+	if _, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(typeName)); err != nil {
+		return nil, err
+	}
+	numbers := make([]int32, 0, 8)
+	protoregistry.GlobalTypes.RangeExtensionsByMessage(protoreflect.FullName(typeName), func(ext protoreflect.ExtensionType) bool {
+		numbers = append(numbers, int32(ext.TypeDescriptor().Number()))
+		return true
+	})
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] < numbers[j]
+	})
+	return numbers, nil
+}
+
+// fileDescWithDependencies returns serialized @fd and all its dependencies that are not in @sentFileDescriptors,
+// as client caches file descriptors that have been sent on one stream
+func fileDescWithDependencies(fd protoreflect.FileDescriptor, sentFileDescriptors map[string]bool) ([][]byte, error) {
+	rsp := make([][]byte, 0, 4)
+	queue := []protoreflect.FileDescriptor{fd}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if sentFileDescriptors[current.Path()] {
+			continue
+		}
+		sentFileDescriptors[current.Path()] = true
+		data, err := proto.Marshal(protodesc.ToFileDescriptorProto(current))
+		if err != nil {
+			return nil, err
+		}
+		rsp = append(rsp, data)
+		imports := current.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			queue = append(queue, imports.Get(i).FileDescriptor)
+		}
+	}
+	return rsp, nil
+}
+
+// ServerReflectionInfo is the impl of rpb.ServerReflectionServer
+func (r *reflectionService) ServerReflectionInfo(stream rpb.ServerReflection_ServerReflectionInfoServer) error {
+	sentFileDescriptors := make(map[string]bool)
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		out := &rpb.ServerReflectionResponse{
+			ValidHost:       in.Host,
+			OriginalRequest: in,
+		}
+		var fd protoreflect.FileDescriptor
+		switch req := in.MessageRequest.(type) {
+		case *rpb.ServerReflectionRequest_FileByFilename:
+			fd, err = protoregistry.GlobalFiles.FindFileByPath(req.FileByFilename)
+		case *rpb.ServerReflectionRequest_FileContainingSymbol:
+			fd, err = r.fileDescContainingSymbol(req.FileContainingSymbol)
+		case *rpb.ServerReflectionRequest_FileContainingExtension:
+			fd, err = r.fileDescContainingExtension(req.FileContainingExtension.ContainingType, req.FileContainingExtension.ExtensionNumber)
+		case *rpb.ServerReflectionRequest_AllExtensionNumbersOfType:
+			var numbers []int32
+			if numbers, err = r.allExtensionNumbersOfType(req.AllExtensionNumbersOfType); err == nil {
+				out.MessageResponse = &rpb.ServerReflectionResponse_AllExtensionNumbersResponse{
+					AllExtensionNumbersResponse: &rpb.ExtensionNumberResponse{
+						BaseTypeName:    req.AllExtensionNumbersOfType,
+						ExtensionNumber: numbers,
+					},
+				}
+			}
+		case *rpb.ServerReflectionRequest_ListServices:
+			out.MessageResponse = &rpb.ServerReflectionResponse_ListServicesResponse{
+				ListServicesResponse: &rpb.ListServiceResponse{
+					Service: r.listServices(),
+				},
+			}
+		default:
+			return perrors.Errorf("invalid MessageRequest: %v", in.MessageRequest)
+		}
+
+		if err == nil && fd != nil {
+			var fdBytes [][]byte
+			if fdBytes, err = fileDescWithDependencies(fd, sentFileDescriptors); err == nil {
+				out.MessageResponse = &rpb.ServerReflectionResponse_FileDescriptorResponse{
+					FileDescriptorResponse: &rpb.FileDescriptorResponse{FileDescriptorProto: fdBytes},
+				}
+			}
+		}
+		if err != nil {
+			out.MessageResponse = &rpb.ServerReflectionResponse_ErrorResponse{
+				ErrorResponse: &rpb.ErrorResponse{
+					ErrorCode:    int32(codes.NotFound),
+					ErrorMessage: err.Error(),
+				},
+			}
+		}
+
+		if err := stream.Send(out); err != nil {
+			return err
+		}
+	}
+}
+
+func reflectionInfoHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(rpb.ServerReflectionServer).ServerReflectionInfo(&reflectionInfoServer{stream})
+}
+
+// reflectionInfoServer is the impl of rpb.ServerReflection_ServerReflectionInfoServer
+type reflectionInfoServer struct {
+	grpc.ServerStream
+}
+
+func (x *reflectionInfoServer) Send(m *rpb.ServerReflectionResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *reflectionInfoServer) Recv() (*rpb.ServerReflectionRequest, error) {
+	m := new(rpb.ServerReflectionRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var reflectionServiceDesc = grpc.ServiceDesc{
+	ServiceName: ReflectionServiceName,
+	HandlerType: (*rpb.ServerReflectionServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ServerReflectionInfo",
+			Handler:       reflectionInfoHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "reflection/grpc_reflection_v1alpha/reflection.proto",
+}
@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/config"
+)
+
+func TestReflectionService(t *testing.T) {
+	server, url := startTestServer(t, &sync.Map{}, config.NewTripleOption(config.WithServerReflection()))
+	defer server.Stop()
+
+	cc, err := grpc.Dial(url.Location, grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer cc.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	stream, err := rpb.NewServerReflectionClient(cc).ServerReflectionInfo(ctx)
+	assert.Nil(t, err)
+
+	// list services
+	err = stream.Send(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	assert.Nil(t, err)
+	rsp, err := stream.Recv()
+	assert.Nil(t, err)
+	services := rsp.GetListServicesResponse().GetService()
+	assert.Equal(t, 2, len(services))
+	assert.Equal(t, HealthServiceName, services[0].Name)
+	assert.Equal(t, ReflectionServiceName, services[1].Name)
+
+	// file containing symbol
+	err = stream.Send(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: HealthServiceName},
+	})
+	assert.Nil(t, err)
+	rsp, err = stream.Recv()
+	assert.Nil(t, err)
+	fdBytes := rsp.GetFileDescriptorResponse().GetFileDescriptorProto()
+	assert.Equal(t, 1, len(fdBytes))
+	fd := &descriptorpb.FileDescriptorProto{}
+	assert.Nil(t, proto.Unmarshal(fdBytes[0], fd))
+	assert.Equal(t, "grpc/health/v1/health.proto", fd.GetName())
+	assert.Equal(t, "Health", fd.GetService()[0].GetName())
+
+	// unknown symbol
+	err = stream.Send(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: "not.Exist"},
+	})
+	assert.Nil(t, err)
+	rsp, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.NotNil(t, rsp.GetErrorResponse())
+
+	assert.Nil(t, stream.CloseSend())
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,214 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	logger "github.com/dubbogo/gost/dubbogo/logger"
+
+	perrors "github.com/pkg/errors"
+)
+
+const (
+	// StaticResolverScheme is the scheme of target with fixed addresses, e.g. static:///a:20000,b:20000
+	StaticResolverScheme = "static"
+	// FileResolverScheme is the scheme of target whose addresses are in a json file,
+	// e.g. file:///etc/triple/endpoints.json, and the file is like {"addresses": ["a:20000", "b:20000"]}
+	FileResolverScheme = "file"
+
+	// fileResolveInterval is the interval to check whether the file of file resolver changes
+	fileResolveInterval = time.Second * 5
+)
+
+// Target is the target of client, in format scheme://authority/endpoint
+type Target struct {
+	Scheme    string
+	Authority string
+	Endpoint  string
+}
+
+// ParseTarget parses @target in format scheme://authority/endpoint
+func ParseTarget(target string) (*Target, error) {
+	i := strings.Index(target, "://")
+	if i <= 0 {
+		return nil, perrors.Errorf("invalid triple target %s, which should be scheme://authority/endpoint", target)
+	}
+	t := &Target{Scheme: target[:i]}
+	rest := target[i+len("://"):]
+	j := strings.Index(rest, "/")
+	if j < 0 {
+		return nil, perrors.Errorf("invalid triple target %s, which should be scheme://authority/endpoint", target)
+	}
+	t.Authority, t.Endpoint = rest[:j], rest[j+1:]
+	return t, nil
+}
+
+// Resolver resolves target of client to addresses, and watches their changes
+type Resolver interface {
+	// Start starts resolving, @update is called with all addresses when they are resolved or changed
+	Start(update func(addrs []string)) error
+	// Close stops resolving
+	Close()
+}
+
+// ResolverBuilder builds Resolver of @target
+type ResolverBuilder func(target *Target) (Resolver, error)
+
+var (
+	resolversLock sync.RWMutex
+	resolvers     = map[string]ResolverBuilder{
+		StaticResolverScheme: newStaticResolver,
+		FileResolverScheme:   newFileResolver,
+	}
+)
+
+// RegisterResolver registers @builder of resolver for targets with @scheme, it replaces the registered one
+func RegisterResolver(scheme string, builder ResolverBuilder) {
+	resolversLock.Lock()
+	defer resolversLock.Unlock()
+	resolvers[scheme] = builder
+}
+
+// buildResolver builds resolver of @target by its scheme
+func buildResolver(target *Target) (Resolver, error) {
+	resolversLock.RLock()
+	builder, ok := resolvers[target.Scheme]
+	resolversLock.RUnlock()
+	if !ok {
+		return nil, perrors.Errorf("triple resolver of scheme %s is not registered", target.Scheme)
+	}
+	return builder(target)
+}
+
+// staticResolver resolves addresses separated by comma in endpoint of target
+type staticResolver struct {
+	addrs []string
+}
+
+func newStaticResolver(target *Target) (Resolver, error) {
+	addrs := make([]string, 0)
+	for _, addr := range strings.Split(target.Endpoint, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, perrors.Errorf("static triple target has no address")
+	}
+	return &staticResolver{addrs: addrs}, nil
+}
+
+func (r *staticResolver) Start(update func(addrs []string)) error {
+	update(r.addrs)
+	return nil
+}
+
+func (r *staticResolver) Close() {}
+
+// fileContent is the content of file of file resolver
+type fileContent struct {
+	Addresses []string `json:"addresses"`
+}
+
+// fileResolver resolves addresses in a json file, and reloads the file every fileResolveInterval
+type fileResolver struct {
+	path      string
+	update    func(addrs []string)
+	addrs     []string
+	closeChan chan struct{}
+	once      sync.Once
+}
+
+func newFileResolver(target *Target) (Resolver, error) {
+	return &fileResolver{
+		path:      "/" + target.Endpoint,
+		closeChan: make(chan struct{}),
+	}, nil
+}
+
+func (r *fileResolver) Start(update func(addrs []string)) error {
+	r.update = update
+	if err := r.refresh(); err != nil {
+		return err
+	}
+	go r.run()
+	return nil
+}
+
+// run reloads the file every fileResolveInterval until resolver is closed
+func (r *fileResolver) run() {
+	ticker := time.NewTicker(fileResolveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.closeChan:
+			return
+		case <-ticker.C:
+			if err := r.refresh(); err != nil {
+				logger.Warnf("triple file resolver reload %s error = %v", r.path, err)
+			}
+		}
+	}
+}
+
+// refresh reads the file, and updates addresses if they change
+func (r *fileResolver) refresh() error {
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return perrors.WithStack(err)
+	}
+	content := &fileContent{}
+	if err := json.Unmarshal(data, content); err != nil {
+		return perrors.Wrapf(err, "invalid triple endpoints file %s", r.path)
+	}
+	addrs := append([]string(nil), content.Addresses...)
+	sort.Strings(addrs)
+	if equalStrings(addrs, r.addrs) {
+		return nil
+	}
+	r.addrs = addrs
+	r.update(addrs)
+	return nil
+}
+
+func (r *fileResolver) Close() {
+	r.once.Do(func() {
+		close(r.closeChan)
+	})
+}
+
+// equalStrings returns if @a and @b have the same strings in the same order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
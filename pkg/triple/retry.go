@@ -0,0 +1,419 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	logger "github.com/dubbogo/gost/dubbogo/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+const (
+	// maxRetryAttempts limits MaxAttempts of retry policy, as grpc does
+	maxRetryAttempts = 5
+
+	// retryBufferLimit limits size of buffered messages of a stream, a stream sending more messages is not retried
+	retryBufferLimit = 256 * 1024
+)
+
+// retryThrottler is a token bucket shared by invocations of a client, which stops retrying when many attempts fail
+type retryThrottler struct {
+	maxTokens  float64
+	tokenRatio float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// newRetryThrottler returns retryThrottler of @throttling, or nil if @throttling is nil
+func newRetryThrottler(throttling *config.RetryThrottling) *retryThrottler {
+	if throttling == nil {
+		return nil
+	}
+	return &retryThrottler{
+		maxTokens:  throttling.MaxTokens,
+		tokenRatio: throttling.TokenRatio,
+		tokens:     throttling.MaxTokens,
+	}
+}
+
+// onFailure takes a token for failed attempt, it returns true if retry is throttled
+func (rt *retryThrottler) onFailure() bool {
+	if rt == nil {
+		return false
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.tokens--
+	if rt.tokens < 0 {
+		rt.tokens = 0
+	}
+	return rt.tokens <= rt.maxTokens/2
+}
+
+// onSuccess adds tokens for successful invocation
+func (rt *retryThrottler) onSuccess() {
+	if rt == nil {
+		return
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.tokens += rt.tokenRatio
+	if rt.tokens > rt.maxTokens {
+		rt.tokens = rt.maxTokens
+	}
+}
+
+// retryer decides whether failed attempts of an invocation should be retried, and the backoff before retrying
+type retryer struct {
+	policy    *config.RetryPolicy
+	throttler *retryThrottler
+
+	attempts int
+	// backoff is the max backoff before next retry
+	backoff time.Duration
+}
+
+// newRetryer returns retryer with @policy, it returns nil if @policy doesn't allow retrying
+func newRetryer(policy *config.RetryPolicy, throttler *retryThrottler) *retryer {
+	if policy == nil || policy.MaxAttempts < 2 {
+		return nil
+	}
+	return &retryer{
+		policy:    policy,
+		throttler: throttler,
+		backoff:   policy.InitialBackoff,
+	}
+}
+
+// onSuccess is called when the invocation succeeds
+func (r *retryer) onSuccess() {
+	r.throttler.onSuccess()
+}
+
+// shouldRetry is called when an attempt fails with @err, @pushback is the value of pushback trailer sent by server.
+// It returns the backoff before retrying, and false if the invocation shouldn't be retried.
+func (r *retryer) shouldRetry(err error, pushback string) (time.Duration, bool) {
+	r.attempts++
+	if !containsCode(status.Code(err), r.policy.RetryableStatusCodes) {
+		return 0, false
+	}
+	if r.throttler.onFailure() {
+		logger.Warnf("triple retry is throttled, err = %v", err)
+		return 0, false
+	}
+	maxAttempts := r.policy.MaxAttempts
+	if maxAttempts > maxRetryAttempts {
+		maxAttempts = maxRetryAttempts
+	}
+	if r.attempts >= maxAttempts {
+		return 0, false
+	}
+
+	if pushback != "" {
+		delay, ok := parsePushback(pushback)
+		if ok {
+			r.backoff = r.policy.InitialBackoff
+		}
+		return delay, ok
+	}
+
+	delay := time.Duration(rand.Float64() * float64(r.backoff))
+	r.backoff = time.Duration(float64(r.backoff) * r.policy.BackoffMultiplier)
+	if r.backoff > r.policy.MaxBackoff {
+		r.backoff = r.policy.MaxBackoff
+	}
+	return delay, true
+}
+
+// containsCode returns if @code is one of @codeList
+func containsCode(code codes.Code, codeList []codes.Code) bool {
+	for _, c := range codeList {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryUnary sends serialized request @data to @path, and retries failed attempts by @r,
+// each attempt prefers a connection that is not tried
+func (t *TripleClient) retryUnary(ctx context.Context, path string, data []byte, reply interface{}, r *retryer) error {
+	var tried []*TripleClient
+	for {
+		var trailer http.Header
+		conn, err := t.pick(ctx, path, tried)
+		if err == nil {
+			tried = append(tried, conn)
+			var rspData []byte
+			if rspData, trailer, err = conn.h2Controller.unaryInvoke(ctx, path, data); err == nil {
+				r.onSuccess()
+				return conn.h2Controller.unmarshalResponse(rspData, reply)
+			}
+		}
+		delay, ok := r.shouldRetry(err, trailer.Get(codec.TrailerKeyGrpcRetryPushbackMs))
+		if !ok {
+			return err
+		}
+		logger.Warnf("triple unary invoke %s failed with err = %v, retry after %v", path, err, delay)
+		if err := waitRetry(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// parsePushback returns the delay in @pushback trailer, and false if server asks not to retry,
+// which is a negative or invalid value
+func parsePushback(pushback string) (time.Duration, bool) {
+	ms, err := strconv.Atoi(pushback)
+	if err != nil || ms < 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// waitRetry waits @delay before retrying, it returns error if @ctx is done first
+func waitRetry(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rawMsgSender is client stream that can send serialized message
+type rawMsgSender interface {
+	SendRawMsg(data []byte) error
+}
+
+// retryClientStream is grpc.ClientStream that retries streaming invocation before the first response message arrives.
+// Sent messages are buffered to be sent again in new attempt, until the stream is committed.
+type retryClientStream struct {
+	ctx     context.Context
+	client  *TripleClient
+	path    string
+	retryer *retryer
+	// serializer serializes messages of all attempts
+	serializer common.Dubbo3Serializer
+
+	mu     sync.Mutex
+	stream grpc.ClientStream
+	// tried are connections of attempts
+	tried      []*TripleClient
+	buffer     [][]byte
+	bufferSize int
+	sendClosed bool
+	// committed is true when the stream can't be retried any more, and buffer is released
+	committed bool
+}
+
+// newRetryClientStream starts streaming invocation with @path, which is retried by @r,
+// messages are serialized by @serializer
+func newRetryClientStream(ctx context.Context, client *TripleClient, path string, serializer common.Dubbo3Serializer,
+	r *retryer) (*retryClientStream, error) {
+	conn, err := client.pick(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := conn.h2Controller.streamInvoke(ctx, path, serializer)
+	if err != nil {
+		return nil, err
+	}
+	return &retryClientStream{
+		ctx:        ctx,
+		client:     client,
+		path:       path,
+		retryer:    r,
+		serializer: serializer,
+		stream:     cs,
+		tried:      []*TripleClient{conn},
+	}, nil
+}
+
+func (rs *retryClientStream) currentStream() grpc.ClientStream {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.stream
+}
+
+func (rs *retryClientStream) Header() (metadata.MD, error) {
+	return rs.currentStream().Header()
+}
+
+func (rs *retryClientStream) Trailer() metadata.MD {
+	return rs.currentStream().Trailer()
+}
+
+func (rs *retryClientStream) Context() context.Context {
+	return rs.ctx
+}
+
+// SendMsg sends @m in current attempt, and buffers it if the stream isn't committed.
+// Lock isn't held while sending, as sending may block until server receives, while RecvMsg needs the lock too.
+func (rs *retryClientStream) SendMsg(m interface{}) error {
+	data, err := rs.serializer.MarshalRequest(m)
+	if err != nil {
+		logger.Errorf("client request marshal error = %v", err)
+		return err
+	}
+	rs.mu.Lock()
+	if !rs.committed {
+		rs.buffer = append(rs.buffer, data)
+		rs.bufferSize += len(data)
+		if rs.bufferSize > retryBufferLimit {
+			rs.commit()
+		}
+	}
+	cs, committed := rs.stream, rs.committed
+	rs.mu.Unlock()
+	err = cs.(rawMsgSender).SendRawMsg(data)
+	if err == io.EOF && !committed {
+		// current attempt is finished by server, the message is sent again if it's retried in RecvMsg
+		return nil
+	}
+	return err
+}
+
+func (rs *retryClientStream) CloseSend() error {
+	rs.mu.Lock()
+	rs.sendClosed = true
+	cs := rs.stream
+	rs.mu.Unlock()
+	return cs.CloseSend()
+}
+
+// RecvMsg receives message to @m, failed attempt is retried if no message is received before
+func (rs *retryClientStream) RecvMsg(m interface{}) error {
+	for {
+		cs := rs.currentStream()
+		err := cs.RecvMsg(m)
+
+		rs.mu.Lock()
+		if rs.committed {
+			rs.mu.Unlock()
+			return err
+		}
+		if err == nil || err == io.EOF {
+			rs.commit()
+			rs.retryer.onSuccess()
+			rs.mu.Unlock()
+			return err
+		}
+		delay, ok := rs.retryer.shouldRetry(err, getFirst(cs.Trailer(), codec.TrailerKeyGrpcRetryPushbackMs))
+		if !ok {
+			rs.commit()
+			rs.mu.Unlock()
+			return err
+		}
+		rs.mu.Unlock()
+
+		logger.Warnf("triple stream %s failed with err = %v, retry after %v", rs.path, err, delay)
+		if waitErr := waitRetry(rs.ctx, delay); waitErr != nil {
+			return waitErr
+		}
+		if retryErr := rs.retry(err); retryErr != nil {
+			return retryErr
+		}
+	}
+}
+
+// retry starts a new attempt on a connection that is not tried if possible, and sends buffered messages again.
+// Lock isn't held while picking connection and sending, messages buffered by SendMsg meanwhile are sent by retry
+// too, and the attempt becomes current stream after all buffered messages are sent. @err of failed attempt is
+// returned if the stream is committed meanwhile, as messages are released.
+func (rs *retryClientStream) retry(err error) error {
+	rs.mu.Lock()
+	tried := append([]*TripleClient(nil), rs.tried...)
+	rs.mu.Unlock()
+	conn, pickErr := rs.client.pick(rs.ctx, rs.path, tried)
+	if pickErr != nil {
+		return pickErr
+	}
+	cs, invokeErr := conn.h2Controller.streamInvoke(rs.ctx, rs.path, rs.serializer)
+	if invokeErr != nil {
+		return invokeErr
+	}
+	sent := 0
+	for {
+		rs.mu.Lock()
+		if rs.committed {
+			rs.mu.Unlock()
+			return err
+		}
+		pending := rs.buffer[sent:]
+		if len(pending) == 0 {
+			rs.tried = append(rs.tried, conn)
+			rs.stream = cs
+			sendClosed := rs.sendClosed
+			rs.mu.Unlock()
+			if sendClosed {
+				return cs.CloseSend()
+			}
+			return nil
+		}
+		rs.mu.Unlock()
+		for _, data := range pending {
+			if sendErr := cs.(rawMsgSender).SendRawMsg(data); sendErr != nil {
+				// attempt is finished by server, RecvMsg returns its status
+				rs.mu.Lock()
+				rs.tried = append(rs.tried, conn)
+				rs.stream = cs
+				rs.mu.Unlock()
+				return nil
+			}
+		}
+		sent += len(pending)
+	}
+}
+
+// commit stops retrying and releases buffer, it must be called with lock
+func (rs *retryClientStream) commit() {
+	rs.committed = true
+	rs.buffer = nil
+	rs.bufferSize = 0
+}
+
+// getFirst returns the first value of @key in @md, or "" if it doesn't exist
+func getFirst(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
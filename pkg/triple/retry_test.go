@@ -0,0 +1,216 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+const flakyServiceName = "triple.test.FlakyService"
+
+// flakyService is a pb service whose methods fail with Unavailable until they are called for failures+1 times,
+// and the first call is delayed by firstDelay
+type flakyService struct {
+	testService
+	failures   int32
+	firstDelay int64
+	calls      int32
+}
+
+// newFlakyService returns flakyService that fails @failures times, and delays the first call by @firstDelay
+func newFlakyService(failures int32, firstDelay time.Duration) *flakyService {
+	return &flakyService{failures: failures, firstDelay: int64(firstDelay)}
+}
+
+// reset resets calls of service, and sets @failures and @firstDelay
+func (f *flakyService) reset(failures int32, firstDelay time.Duration) {
+	atomic.StoreInt32(&f.failures, failures)
+	atomic.StoreInt64(&f.firstDelay, int64(firstDelay))
+	atomic.StoreInt32(&f.calls, 0)
+}
+
+func (f *flakyService) ServiceDesc() *grpc.ServiceDesc {
+	return &flakyServiceDesc
+}
+
+func (f *flakyService) call() error {
+	calls := atomic.AddInt32(&f.calls, 1)
+	if calls == 1 {
+		time.Sleep(time.Duration(atomic.LoadInt64(&f.firstDelay)))
+	}
+	if calls <= atomic.LoadInt32(&f.failures) {
+		return status.Err(codes.Unavailable, "flaky")
+	}
+	return nil
+}
+
+var flakyServiceDesc = grpc.ServiceDesc{
+	ServiceName: flakyServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Call",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(healthpb.HealthCheckRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				if err := srv.(*flakyService).call(); err != nil {
+					return nil, err
+				}
+				return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "CallStream",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				in := new(healthpb.HealthCheckRequest)
+				if err := stream.RecvMsg(in); err != nil {
+					return err
+				}
+				if err := srv.(*flakyService).call(); err != nil {
+					return err
+				}
+				return stream.SendMsg(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+			},
+			ServerStreams: true,
+		},
+	},
+}
+
+// testStubImpl is the impl of pb client in tests, its stub is the TripleConn itself
+type testStubImpl struct{}
+
+func (testStubImpl) GetDubboStub(cc *TripleConn) interface{} {
+	return cc
+}
+
+func TestRetryer(t *testing.T) {
+	policy := &config.RetryPolicy{
+		MaxAttempts:          10,
+		InitialBackoff:       time.Millisecond * 100,
+		MaxBackoff:           time.Millisecond * 250,
+		BackoffMultiplier:    2,
+		RetryableStatusCodes: []codes.Code{codes.Unavailable},
+	}
+	assert.Nil(t, newRetryer(&config.RetryPolicy{MaxAttempts: 1}, nil))
+
+	r := newRetryer(policy, nil)
+	unavailable := status.Err(codes.Unavailable, "unavailable")
+	for _, maxBackoff := range []time.Duration{time.Millisecond * 100, time.Millisecond * 200, time.Millisecond * 250, time.Millisecond * 250} {
+		delay, ok := r.shouldRetry(unavailable, "")
+		assert.True(t, ok)
+		assert.True(t, delay >= 0 && delay < maxBackoff)
+	}
+	// max attempts is limited to 5
+	_, ok := r.shouldRetry(unavailable, "")
+	assert.False(t, ok)
+
+	// status code not retryable
+	r = newRetryer(policy, nil)
+	_, ok = r.shouldRetry(status.Err(codes.Internal, "internal"), "")
+	assert.False(t, ok)
+
+	// pushback from server
+	r = newRetryer(policy, nil)
+	delay, ok := r.shouldRetry(unavailable, "300")
+	assert.True(t, ok)
+	assert.Equal(t, time.Millisecond*300, delay)
+	_, ok = r.shouldRetry(unavailable, "-1")
+	assert.False(t, ok)
+}
+
+func TestRetryThrottler(t *testing.T) {
+	throttler := newRetryThrottler(&config.RetryThrottling{MaxTokens: 4, TokenRatio: 0.5})
+	assert.False(t, throttler.onFailure())
+	// tokens are 2, which is not more than half of max tokens
+	assert.True(t, throttler.onFailure())
+	// tokens are 3.5 after successes, and 2.5 after failure
+	throttler.onSuccess()
+	throttler.onSuccess()
+	throttler.onSuccess()
+	assert.False(t, throttler.onFailure())
+
+	var nilThrottler *retryThrottler
+	assert.False(t, nilThrottler.onFailure())
+}
+
+func TestRetry(t *testing.T) {
+	service := newFlakyService(2, 0)
+	serviceMap := &sync.Map{}
+	serviceMap.Store(flakyServiceName, service)
+	server, url := startTestServer(t, serviceMap, nil)
+	defer server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	client, err := NewTripleClient(url, testStubImpl{}, config.NewTripleOption(
+		config.WithMethodConfig("/"+flakyServiceName, &config.MethodConfig{
+			RetryPolicy: &config.RetryPolicy{
+				MaxAttempts:          3,
+				InitialBackoff:       time.Millisecond * 10,
+				MaxBackoff:           time.Millisecond * 50,
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []codes.Code{codes.Unavailable},
+			},
+		}),
+	))
+	assert.Nil(t, err)
+	defer client.Close()
+
+	// unary invocation succeeds at the third attempt
+	reply := &healthpb.HealthCheckResponse{}
+	err = client.Request(ctx, "/"+flakyServiceName+"/Call", &healthpb.HealthCheckRequest{}, reply)
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, reply.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&service.calls))
+
+	// all attempts fail
+	service.reset(3, 0)
+	err = client.Request(ctx, "/"+flakyServiceName+"/Call", &healthpb.HealthCheckRequest{}, reply)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&service.calls))
+
+	// streaming invocation is retried with buffered request before the first response
+	service.reset(2, 0)
+	clientStream, err := client.StreamRequest(ctx, "/"+flakyServiceName+"/CallStream")
+	assert.Nil(t, err)
+	assert.Nil(t, clientStream.SendMsg(&healthpb.HealthCheckRequest{}))
+	reply = &healthpb.HealthCheckResponse{}
+	assert.Nil(t, clientStream.RecvMsg(reply))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, reply.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&service.calls))
+}
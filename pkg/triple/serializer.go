@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+)
+
+import (
+	"google.golang.org/grpc/metadata"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+// acceptedSerializerTypes returns serializers of requests that @service accepts
+func (hc *H2Controller) acceptedSerializerTypes(service common.Dubbo3GrpcService) []common.TripleSerializerName {
+	if _, ok := service.(builtinService); ok {
+		// builtin services are defined by protobuf, they don't depend on serializer that user chooses
+		return []common.TripleSerializerName{common.PBSerializerName}
+	}
+	if typer, ok := service.(common.SerializerTyper); ok {
+		return typer.SerializerTypes()
+	}
+	return hc.option.GetSerializerTypes()
+}
+
+// negotiateSerializer chooses serializer of request of @methodName of @service, whose content-type is in @reqMD,
+// from serializers that service accepts. Protobuf and wrapper requests have the same content-subtype "proto",
+// if both are accepted, see isWrapperRequest for which one is chosen.
+// It returns option with SerializerType of the chosen one.
+func (hc *H2Controller) negotiateSerializer(service common.Dubbo3GrpcService, methodName string,
+	reqMD metadata.MD) (*config.Option, common.Dubbo3Serializer, error) {
+	subtype := contentSubtype(reqMD)
+	serializers := make(map[common.TripleSerializerName]common.Dubbo3Serializer)
+	var serializerType common.TripleSerializerName
+	for _, typ := range hc.acceptedSerializerTypes(service) {
+		serializer, err := common.GetDubbo3Serializer(typ)
+		if err != nil || common.GetContentSubtype(serializer) != subtype {
+			continue
+		}
+		if len(serializers) == 0 {
+			serializerType = typ
+		}
+		serializers[typ] = serializer
+	}
+	if len(serializers) == 0 {
+		return nil, nil, status.Errorf(codes.Unimplemented, "content-subtype %s is not accepted by method %s",
+			subtype, methodName)
+	}
+
+	_, pbOk := serializers[common.PBSerializerName]
+	_, wrapperOk := serializers[common.TripleHessianWrapperSerializerName]
+	if pbOk && wrapperOk {
+		serializerType = common.PBSerializerName
+		if isWrapperRequest(service, methodName, reqMD) {
+			serializerType = common.TripleHessianWrapperSerializerName
+		}
+	}
+	serializer := serializers[serializerType]
+	if serializerType == common.TripleHessianWrapperSerializerName {
+		// responses are serialized by inner serialization of requests
+		serializer = codec.NewTripleWrapperServerSerializer()
+	}
+
+	opt := hc.option
+	if opt.SerializerType != serializerType {
+		newOpt := *hc.option
+		newOpt.SerializerType = serializerType
+		opt = &newOpt
+	}
+	return opt, serializer, nil
+}
+
+// isWrapperRequest returns true if request of @methodName of @service with metadata @reqMD is serialized by
+// triple wrapper serializer rather than protobuf. Clients of this package tell it by header codec.TripleSerializer,
+// otherwise it depends on how the method is registered: methods in grpc.Desc of service are protobuf ones,
+// and other methods, which are invoked by proxy impl, are wrapper ones. Services with wrapper streaming methods
+// that serve other wrapper clients can declare serializers they accept by common.SerializerTyper.
+func isWrapperRequest(service common.Dubbo3GrpcService, methodName string, reqMD metadata.MD) bool {
+	if serializer := reqMD.Get(codec.TripleSerializer); len(serializer) > 0 {
+		return serializer[0] == string(common.TripleHessianWrapperSerializerName)
+	}
+	return !hasMethodDesc(service, methodName)
+}
+
+// hasMethodDesc returns true if @methodName is unary method or streaming method in grpc.Desc of @service
+func hasMethodDesc(service common.Dubbo3GrpcService, methodName string) bool {
+	desc := service.ServiceDesc()
+	if desc == nil {
+		return false
+	}
+	for _, method := range desc.Methods {
+		if method.MethodName == methodName {
+			return true
+		}
+	}
+	for _, stream := range desc.Streams {
+		if stream.StreamName == methodName {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapperSerializeTypeKey is context key of serialize type of args in triple wrapper
+type wrapperSerializeTypeKey struct{}
+
+// NewWrapperSerializeTypeContext returns ctx of invocation whose args are serialized by @serializeType in triple wrapper,
+// which is one of "hessian2", "json" and "fastjson". It works for client with triple-hessian-wrapper serializer.
+func NewWrapperSerializeTypeContext(ctx context.Context, serializeType common.TripleSerializerName) context.Context {
+	return context.WithValue(ctx, wrapperSerializeTypeKey{}, serializeType)
+}
+
+// callSerializer returns serializer of invocation with @ctx, which serializes args in serialize type of
+// NewWrapperSerializeTypeContext if client uses triple wrapper, otherwise it's serializer of client
+func (t *TripleClient) callSerializer(ctx context.Context) (common.Dubbo3Serializer, error) {
+	serializeType, ok := ctx.Value(wrapperSerializeTypeKey{}).(common.TripleSerializerName)
+	if !ok || t.opt.SerializerType != common.TripleHessianWrapperSerializerName {
+		return t.serializer, nil
+	}
+	return codec.NewTripleWrapperSerializer(string(serializeType))
+}
@@ -0,0 +1,236 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	dubboCommon "github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/proxy/proxy_factory"
+	dubboConstant "github.com/dubbogo/gost/dubbogo/constant"
+	gxprotocol "github.com/dubbogo/gost/dubbogo/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/common"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+const pbEchoServiceName = "triple.test.PBEchoService"
+
+// pbEchoService is echoService that only accepts protobuf requests
+type pbEchoService struct {
+	echoService
+}
+
+func (e *pbEchoService) SerializerTypes() []common.TripleSerializerName {
+	return []common.TripleSerializerName{common.PBSerializerName}
+}
+
+func TestMultipleSerializers(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(echoServiceName, &echoService{})
+	serviceMap.Store(pbEchoServiceName, &pbEchoService{})
+	serviceMap.Store(hessianStreamServiceName, &hessianStreamService{})
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(config.WithSerializerTypes(
+		common.PBSerializerName, common.JSONSerializerName, common.TripleHessianWrapperSerializerName)))
+	defer server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	// protobuf and json clients invoke the same pb service
+	for _, serializerType := range []common.TripleSerializerName{common.PBSerializerName, common.JSONSerializerName} {
+		client, err := NewTripleClient(url, testStubImpl{}, config.NewTripleOption(config.WithSerializerType(serializerType)))
+		assert.Nil(t, err)
+		reply := &wrapperspb.StringValue{}
+		assert.Nil(t, client.Request(ctx, "/"+echoServiceName+"/Echo", wrapperspb.String("hello"), reply))
+		assert.Equal(t, "hello", reply.Value)
+
+		// pbEchoService doesn't accept json requests
+		reply = &wrapperspb.StringValue{}
+		err = client.Request(ctx, "/"+pbEchoServiceName+"/Echo", wrapperspb.String("hello"), reply)
+		if serializerType == common.PBSerializerName {
+			assert.Nil(t, err)
+			assert.Equal(t, "hello", reply.Value)
+		} else {
+			assert.Equal(t, codes.Unimplemented, status.Code(err))
+		}
+		client.Close()
+	}
+
+	// pb messages whose field 1 is serialize type of triple wrapper are still protobuf requests
+	client, err := NewTripleClient(url, testStubImpl{}, nil)
+	assert.Nil(t, err)
+	for _, serializeType := range []common.TripleSerializerName{
+		common.HessianSerializerName, common.JSONSerializerName, common.FastJSONSerializerName,
+	} {
+		reply := &wrapperspb.StringValue{}
+		assert.Nil(t, client.Request(ctx, "/"+echoServiceName+"/Echo", wrapperspb.String(string(serializeType)), reply))
+		assert.Equal(t, string(serializeType), reply.Value)
+	}
+	client.Close()
+
+	// wrapper client invokes hessian service, whose requests are of the same content-type as protobuf ones
+	hessianOpt := config.WithSerializerType(common.TripleHessianWrapperSerializerName)
+	client, err = NewTripleClient(url, &hessianStreamService{}, config.NewTripleOption(hessianOpt))
+	assert.Nil(t, err)
+	defer client.Close()
+	hessianCtx := context.WithValue(ctx, dubboConstant.DubboCtxKey(dubboConstant.INTERFACE_KEY), hessianStreamServiceName)
+	rsp := client.Invoke("Split", []reflect.Value{reflect.ValueOf(hessianCtx), reflect.ValueOf([]interface{}{"ab"})})
+	assert.False(t, rsp[1].IsValid())
+	splitStream := rsp[0].Interface().(grpc.ClientStream)
+	for _, want := range []string{"a", "b"} {
+		var s string
+		assert.Nil(t, splitStream.RecvMsg(&s))
+		assert.Equal(t, want, s)
+	}
+	var s string
+	assert.Equal(t, io.EOF, splitStream.RecvMsg(&s))
+}
+
+func TestWrapperSerializeType(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(hessianStreamServiceName, &hessianStreamService{})
+	hessianOpt := config.WithSerializerType(common.TripleHessianWrapperSerializerName)
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(hessianOpt))
+	defer server.Stop()
+	// streams are retryable, so that attempts of them are started with serializer of invocation
+	client, err := NewTripleClient(url, &hessianStreamService{}, config.NewTripleOption(hessianOpt,
+		config.WithMethodConfig("/"+hessianStreamServiceName, &config.MethodConfig{
+			RetryPolicy: &config.RetryPolicy{
+				MaxAttempts:          2,
+				InitialBackoff:       time.Millisecond * 10,
+				MaxBackoff:           time.Millisecond * 10,
+				BackoffMultiplier:    1,
+				RetryableStatusCodes: []codes.Code{codes.Unavailable},
+			},
+		}),
+	))
+	assert.Nil(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	ctx = context.WithValue(ctx, dubboConstant.DubboCtxKey(dubboConstant.INTERFACE_KEY), hessianStreamServiceName)
+
+	for _, serializeType := range []common.TripleSerializerName{
+		common.HessianSerializerName, common.JSONSerializerName, common.FastJSONSerializerName,
+	} {
+		sumStream, err := client.StreamRequest(NewWrapperSerializeTypeContext(ctx, serializeType),
+			"/"+hessianStreamServiceName+"/Sum")
+		assert.Nil(t, err)
+		_, retryable := sumStream.(*retryClientStream)
+		assert.True(t, retryable)
+		for _, n := range []int64{1, 2, 3} {
+			assert.Nil(t, sumStream.SendMsg(n))
+		}
+		assert.Nil(t, sumStream.CloseSend())
+		var sum int64
+		assert.Nil(t, sumStream.RecvMsg(&sum))
+		assert.Equal(t, int64(6), sum)
+	}
+
+	_, err = client.StreamRequest(NewWrapperSerializeTypeContext(ctx, "hessian4"), "/"+hessianStreamServiceName+"/Sum")
+	assert.NotNil(t, err)
+}
+
+const wrapperArgServiceName = "triple.test.WrapperArgService"
+
+// WrapperArgPet is a struct arg of WrapperArgService
+type WrapperArgPet struct {
+	Name string `json:"name"`
+}
+
+// WrapperArgService is invoked by proxy invoker of dubbo-go with args of their exact types,
+// it's exported as dubbo-go only registers exported services
+type WrapperArgService struct {
+	proxyImpl gxprotocol.Invoker
+}
+
+func (s *WrapperArgService) Reference() string {
+	return wrapperArgServiceName
+}
+
+func (s *WrapperArgService) SetProxyImpl(impl gxprotocol.Invoker) {
+	s.proxyImpl = impl
+}
+
+func (s *WrapperArgService) GetProxyImpl() gxprotocol.Invoker {
+	return s.proxyImpl
+}
+
+func (s *WrapperArgService) ServiceDesc() *grpc.ServiceDesc {
+	return &grpc.ServiceDesc{ServiceName: wrapperArgServiceName, HandlerType: (*interface{})(nil)}
+}
+
+func (s *WrapperArgService) Describe(ctx context.Context, name string, age int64, pet *WrapperArgPet) (string, error) {
+	return fmt.Sprintf("%s of %d has %s", name, age, pet.Name), nil
+}
+
+func TestWrapperJSONArgs(t *testing.T) {
+	service := &WrapperArgService{}
+	_, err := dubboCommon.ServiceMap.Register(wrapperArgServiceName, "tri", "", "", service)
+	assert.Nil(t, err)
+	defer dubboCommon.ServiceMap.UnRegister(wrapperArgServiceName, "tri",
+		dubboCommon.ServiceKey(wrapperArgServiceName, "", ""))
+	providerURL, err := dubboCommon.NewURL("tri://127.0.0.1:20000/" + wrapperArgServiceName)
+	assert.Nil(t, err)
+	service.SetProxyImpl(&dubboProxyInvoker{invoker: proxy_factory.NewDefaultProxyFactory().GetInvoker(providerURL)})
+
+	serviceMap := &sync.Map{}
+	serviceMap.Store(wrapperArgServiceName, service)
+	hessianOpt := config.WithSerializerType(common.TripleHessianWrapperSerializerName)
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(hessianOpt))
+	defer server.Stop()
+	client, err := NewTripleClient(url, nil, config.NewTripleOption(hessianOpt))
+	assert.Nil(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	ctx = context.WithValue(ctx, dubboConstant.DubboCtxKey(dubboConstant.INTERFACE_KEY), wrapperArgServiceName)
+
+	// json args are deserialized to types of params, rather than float64 and map
+	for _, serializeType := range []common.TripleSerializerName{common.JSONSerializerName, common.FastJSONSerializerName} {
+		var out codec.HessianUnmarshalStruct
+		args := []interface{}{"dubbo", int64(12), &WrapperArgPet{Name: "cat"}}
+		err := client.Request(NewWrapperSerializeTypeContext(ctx, serializeType), "/"+wrapperArgServiceName+"/describe",
+			args, &out)
+		assert.Nil(t, err, serializeType)
+		assert.Equal(t, "dubbo of 12 has cat", out.Val)
+	}
+
+	// args must match params of method
+	var out codec.HessianUnmarshalStruct
+	err = client.Request(NewWrapperSerializeTypeContext(ctx, common.JSONSerializerName),
+		"/"+wrapperArgServiceName+"/describe", []interface{}{"dubbo"}, &out)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,329 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	dubboCommon "github.com/dubbogo/gost/dubbogo"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/tools"
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+const errorServiceName = "triple.test.ErrorService"
+
+const errorServiceMessagePrefix = "message:"
+
+// errorServiceMessages are grpc messages that need percent-encoding,
+// errorService returns status error with errorServiceMessages[i] if service field of request is "message:i"
+var errorServiceMessages = []string{
+	"plain message",
+	"",
+	"multi\nline\r\nmessage",
+	"100% done",
+	"%41 is not encoded",
+	"中文 message",
+	"emoji 😀",
+	"invalid utf8 \xff\xfe end",
+	"tab\tand control \x01",
+}
+
+// errorService is a pb service whose methods always return error, which is chosen by service field of request:
+// status error with details by default, grpc status error, plain error, status error with errorServiceMessages,
+// or panic with a string
+type errorService struct {
+	testService
+}
+
+func (e *errorService) ServiceDesc() *grpc.ServiceDesc {
+	return &errorServiceDesc
+}
+
+func (e *errorService) err(kind string) error {
+	if strings.HasPrefix(kind, errorServiceMessagePrefix) {
+		i, _ := strconv.Atoi(strings.TrimPrefix(kind, errorServiceMessagePrefix))
+		return status.Err(codes.Aborted, errorServiceMessages[i])
+	}
+	switch kind {
+	case "grpc":
+		return grpcstatus.Error(codes.NotFound, "not found")
+	case "plain":
+		return errors.New("plain error")
+	case "panic":
+		panic("handler panic")
+	}
+	st, _ := status.New(codes.PermissionDenied, "bad request").WithDetails(
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "service", Description: "invalid"}}},
+		&errdetails.RetryInfo{RetryDelay: &duration.Duration{Seconds: 1}},
+	)
+	return st.Err()
+}
+
+var errorServiceDesc = grpc.ServiceDesc{
+	ServiceName: errorServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Fail",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(healthpb.HealthCheckRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return nil, srv.(*errorService).err(in.Service)
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "FailStream",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				in := new(healthpb.HealthCheckRequest)
+				if err := stream.RecvMsg(in); err != nil {
+					return err
+				}
+				if err := stream.SendMsg(&healthpb.HealthCheckResponse{}); err != nil {
+					return err
+				}
+				return srv.(*errorService).err(in.Service)
+			},
+			ServerStreams: true,
+		},
+	},
+}
+
+// newTestH2Controller returns client H2Controller connecting to @url, with default option
+func newTestH2Controller(t *testing.T, url *dubboCommon.URL) *H2Controller {
+	hc, err := NewH2Controller(false, nil, url, tools.AddDefaultOption(nil))
+	assert.Nil(t, err)
+	hc.address = url.Location
+	return hc
+}
+
+// assertErrorDetails asserts that @details are what errorService returns
+func assertErrorDetails(t *testing.T, details []interface{}) {
+	assert.Equal(t, 2, len(details))
+	badRequest, ok := details[0].(*errdetails.BadRequest)
+	assert.True(t, ok)
+	assert.Equal(t, "service", badRequest.GetFieldViolations()[0].GetField())
+	retryInfo, ok := details[1].(*errdetails.RetryInfo)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), retryInfo.GetRetryDelay().GetSeconds())
+}
+
+func TestStatusDetails(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(errorServiceName, &errorService{})
+	server, url := startTestServer(t, serviceMap, nil)
+	defer server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	// grpc-go client
+	cc, err := grpc.Dial(url.Location, grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer cc.Close()
+	err = cc.Invoke(ctx, "/"+errorServiceName+"/Fail", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	grpcSt, ok := grpcstatus.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.PermissionDenied, grpcSt.Code())
+	assert.Equal(t, "bad request", grpcSt.Message())
+	assertErrorDetails(t, grpcSt.Details())
+
+	// triple unary invocation
+	hc := newTestH2Controller(t, url)
+	defer hc.Destroy()
+	err = hc.UnaryInvoke(ctx, "/"+errorServiceName+"/Fail", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.PermissionDenied, st.Code())
+	assertErrorDetails(t, st.Details())
+	// triple status error can be read by grpc status package
+	grpcSt, ok = grpcstatus.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.PermissionDenied, grpcSt.Code())
+	assertErrorDetails(t, grpcSt.Details())
+
+	// code of grpc status error returned by handler is preserved
+	err = hc.UnaryInvoke(ctx, "/"+errorServiceName+"/Fail", &healthpb.HealthCheckRequest{Service: "grpc"}, &healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "not found", status.Convert(err).Message())
+
+	// plain error returned by handler is internal error
+	err = hc.UnaryInvoke(ctx, "/"+errorServiceName+"/Fail", &healthpb.HealthCheckRequest{Service: "plain"}, &healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	// triple streaming invocation
+	clientStream, err := hc.StreamInvoke(ctx, "/"+errorServiceName+"/FailStream")
+	assert.Nil(t, err)
+	assert.Nil(t, clientStream.SendMsg(&healthpb.HealthCheckRequest{}))
+	assert.Nil(t, clientStream.RecvMsg(&healthpb.HealthCheckResponse{}))
+	err = clientStream.RecvMsg(&healthpb.HealthCheckResponse{})
+	st, ok = status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.PermissionDenied, st.Code())
+	assertErrorDetails(t, st.Details())
+}
+
+func TestGrpcMessageInterop(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(errorServiceName, &errorService{})
+	tripleServer, tripleURL := startTestServer(t, serviceMap, nil)
+	defer tripleServer.Stop()
+
+	// grpc-go server serving the same service
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	grpcURL := urlWithLocation(newTestURL(t), lst.Addr().String())
+	grpcServer := grpc.NewServer()
+	grpcServer.RegisterService(&errorServiceDesc, &errorService{})
+	go grpcServer.Serve(lst)
+	defer grpcServer.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	cc, err := grpc.Dial(tripleURL.Location, grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer cc.Close()
+	hc := newTestH2Controller(t, grpcURL)
+	defer hc.Destroy()
+
+	for i, msg := range errorServiceMessages {
+		// each invalid utf8 byte is replaced with U+FFFD by both grpc-go and triple
+		want := string([]rune(msg))
+		req := &healthpb.HealthCheckRequest{Service: errorServiceMessagePrefix + strconv.Itoa(i)}
+
+		// grpc-go client -> triple server
+		err := cc.Invoke(ctx, "/"+errorServiceName+"/Fail", req, &healthpb.HealthCheckResponse{})
+		assert.Equal(t, codes.Aborted, status.Code(err), msg)
+		assert.Equal(t, want, status.Convert(err).Message(), msg)
+
+		// triple client -> grpc-go server, grpc-go server sends message in trailers-only response
+		err = hc.UnaryInvoke(ctx, "/"+errorServiceName+"/Fail", req, &healthpb.HealthCheckResponse{})
+		assert.Equal(t, codes.Aborted, status.Code(err), msg)
+		assert.Equal(t, want, status.Convert(err).Message(), msg)
+
+		// triple client -> grpc-go server, grpc-go server sends message in trailers after response
+		clientStream, err := hc.StreamInvoke(ctx, "/"+errorServiceName+"/FailStream")
+		assert.Nil(t, err)
+		assert.Nil(t, clientStream.SendMsg(req))
+		assert.Nil(t, clientStream.RecvMsg(&healthpb.HealthCheckResponse{}))
+		err = clientStream.RecvMsg(&healthpb.HealthCheckResponse{})
+		assert.Equal(t, codes.Aborted, status.Code(err), msg)
+		assert.Equal(t, want, status.Convert(err).Message(), msg)
+	}
+}
+
+func TestTrailersOnlyRsp(t *testing.T) {
+	serviceMap := &sync.Map{}
+	serviceMap.Store(errorServiceName, &errorService{})
+	server, url := startTestServer(t, serviceMap, nil)
+	defer server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	cc, err := grpc.Dial(url.Location, grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer cc.Close()
+	hc := newTestH2Controller(t, url)
+	defer hc.Destroy()
+
+	for _, path := range []string{
+		"/triple.test.UnknownService/Fail",
+		"/" + errorServiceName + "/UnknownMethod",
+		"/" + errorServiceName + "/",
+	} {
+		// grpc-go client
+		err := cc.Invoke(ctx, path, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+		assert.Equal(t, codes.Unimplemented, status.Code(err), path)
+
+		// triple unary invocation
+		err = hc.UnaryInvoke(ctx, path, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+		assert.Equal(t, codes.Unimplemented, status.Code(err), path)
+
+		// triple streaming invocation
+		clientStream, err := hc.StreamInvoke(ctx, path)
+		assert.Nil(t, err)
+		err = clientStream.RecvMsg(&healthpb.HealthCheckResponse{})
+		assert.Equal(t, codes.Unimplemented, status.Code(err), path)
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		methods []string
+	)
+	panicHandler := func(ctx context.Context, method string, value interface{}, stack []byte) {
+		assert.NotNil(t, ctx)
+		assert.Equal(t, "handler panic", value)
+		assert.True(t, strings.Contains(string(stack), "errorService"))
+		lock.Lock()
+		methods = append(methods, method)
+		lock.Unlock()
+	}
+	serviceMap := &sync.Map{}
+	serviceMap.Store(errorServiceName, &errorService{})
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(config.WithPanicHandler(panicHandler)))
+	defer server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	hc := newTestH2Controller(t, url)
+	defer hc.Destroy()
+	req := &healthpb.HealthCheckRequest{Service: "panic"}
+
+	err := hc.UnaryInvoke(ctx, "/"+errorServiceName+"/Fail", req, &healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.True(t, strings.Contains(status.Convert(err).Message(), "handler panic"))
+
+	clientStream, err := hc.StreamInvoke(ctx, "/"+errorServiceName+"/FailStream")
+	assert.Nil(t, err)
+	assert.Nil(t, clientStream.SendMsg(req))
+	assert.Nil(t, clientStream.RecvMsg(&healthpb.HealthCheckResponse{}))
+	err = clientStream.RecvMsg(&healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	// server still works after panic
+	err = hc.UnaryInvoke(ctx, "/"+errorServiceName+"/Fail", &healthpb.HealthCheckRequest{Service: "grpc"}, &healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"/" + errorServiceName + "/Fail", "/" + errorServiceName + "/FailStream"}, methods)
+}
@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+)
+
+import (
+	h2Triple "github.com/dubbogo/net/http2/triple"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+import (
+	"github.com/dubbogo/triple/internal/codec"
+	"github.com/dubbogo/triple/internal/stream"
+)
+
+// unknownServiceHeader is the header of invocation handled by UnknownServiceHandler, whose ctx contains
+// metadata of request headers and the full method path
+type unknownServiceHeader struct {
+	h2Triple.ProtocolHeader
+	md metadata.MD
+}
+
+// FieldToCtx returns ctx with incoming metadata, and server transport stream that returns full method path
+func (h *unknownServiceHeader) FieldToCtx() context.Context {
+	ctx := metadata.NewIncomingContext(h.ProtocolHeader.FieldToCtx(), h.md)
+	return grpc.NewContextWithServerTransportStream(ctx, &methodTransportStream{method: h.GetPath()})
+}
+
+// methodTransportStream is the grpc.ServerTransportStream that only provides method of invocation,
+// so that grpc.MethodFromServerStream works for streams of UnknownServiceHandler
+type methodTransportStream struct {
+	method string
+}
+
+func (m *methodTransportStream) Method() string {
+	return m.method
+}
+
+func (m *methodTransportStream) SetHeader(metadata.MD) error {
+	return nil
+}
+
+func (m *methodTransportStream) SendHeader(metadata.MD) error {
+	return nil
+}
+
+func (m *methodTransportStream) SetTrailer(metadata.MD) error {
+	return nil
+}
+
+// newUnknownServiceStream creates bidirectional server stream of raw payloads for invocation with @header and
+// request metadata @md, which is handled by UnknownServiceHandler. It returns @err if there is no UnknownServiceHandler.
+func (hc *H2Controller) newUnknownServiceStream(header h2Triple.ProtocolHeader, md metadata.MD, err error) (stream.Stream, error) {
+	if hc.option.UnknownServiceHandler == nil {
+		return nil, err
+	}
+	desc := grpc.StreamDesc{
+		StreamName:    header.GetPath(),
+		Handler:       hc.option.UnknownServiceHandler,
+		ServerStreams: true,
+		ClientStreams: true,
+	}
+	return stream.NewServerStream(&unknownServiceHeader{ProtocolHeader: header, md: md}, desc, hc.url, nil,
+		codec.NewRawCodeC(), hc.option)
+}
@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+import (
+	"github.com/dubbogo/triple/pkg/codes"
+	"github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/status"
+)
+
+func TestUnknownServiceHandler(t *testing.T) {
+	methods := make(chan string, 8)
+	// handler echoes raw payloads, and fails invocations of Fail method
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		method, ok := grpc.MethodFromServerStream(stream)
+		assert.True(t, ok)
+		md, ok := metadata.FromIncomingContext(stream.Context())
+		assert.True(t, ok)
+		assert.NotEmpty(t, md.Get("user-agent"))
+		methods <- method
+		if method == "/"+flakyServiceName+"/Fail" {
+			return status.Err(codes.NotFound, "fail")
+		}
+		for {
+			var payload []byte
+			if err := stream.RecvMsg(&payload); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err := stream.SendMsg(payload); err != nil {
+				return err
+			}
+		}
+	}
+	serviceMap := &sync.Map{}
+	serviceMap.Store(flakyServiceName, newFlakyService(0, 0))
+	server, url := startTestServer(t, serviceMap, config.NewTripleOption(config.WithUnknownServiceHandler(handler)))
+	defer server.Stop()
+	client, err := NewTripleClient(url, testStubImpl{}, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	// unary invocation of unregistered service
+	reply := &wrapperspb.StringValue{}
+	assert.Nil(t, client.Request(ctx, "/"+echoServiceName+"/Echo", wrapperspb.String("hello"), reply))
+	assert.Equal(t, "hello", reply.Value)
+	assert.Equal(t, "/"+echoServiceName+"/Echo", <-methods)
+
+	// bidirectional streaming invocation of unregistered method
+	stream, err := client.StreamRequest(ctx, "/"+flakyServiceName+"/Chat")
+	assert.Nil(t, err)
+	for _, value := range []string{"a", "b"} {
+		assert.Nil(t, stream.SendMsg(wrapperspb.String(value)))
+		assert.Nil(t, stream.RecvMsg(reply))
+		assert.Equal(t, value, reply.Value)
+	}
+	assert.Nil(t, stream.CloseSend())
+	assert.Equal(t, io.EOF, stream.RecvMsg(reply))
+	assert.Equal(t, "/"+flakyServiceName+"/Chat", <-methods)
+
+	// status returned by handler
+	err = client.Request(ctx, "/"+flakyServiceName+"/Fail", wrapperspb.String("hello"), reply)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "/"+flakyServiceName+"/Fail", <-methods)
+
+	// registered method is not handled by handler
+	assert.Nil(t, client.Request(ctx, "/"+flakyServiceName+"/Call", wrapperspb.String("hello"), reply))
+	assert.Empty(t, methods)
+}